@@ -671,6 +671,12 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	if remoteInfo.Exists("SwapLimit") && !remoteInfo.GetBool("SwapLimit") {
 		fmt.Fprintf(cli.err, "WARNING: No swap limit support\n")
 	}
+	if remoteInfo.Exists("PidsLimit") && !remoteInfo.GetBool("PidsLimit") {
+		fmt.Fprintf(cli.err, "WARNING: No pids limit support\n")
+	}
+	if remoteInfo.Exists("Seccomp") && !remoteInfo.GetBool("Seccomp") {
+		fmt.Fprintf(cli.err, "WARNING: No seccomp support\n")
+	}
 	if remoteInfo.Exists("IPv4Forwarding") && !remoteInfo.GetBool("IPv4Forwarding") {
 		fmt.Fprintf(cli.err, "WARNING: IPv4 forwarding is disabled.\n")
 	}
@@ -909,6 +915,47 @@ func (cli *DockerCli) CmdPause(args ...string) error {
 	return encounteredError
 }
 
+func (cli *DockerCli) CmdUpdate(args ...string) error {
+	cmd := cli.Subcmd("update", "CONTAINER [CONTAINER...]", "Update the resources of one or more containers", true)
+	var (
+		flMemoryString = cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+		flCpuShares    = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
+		flCpusetCpus   = cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+	)
+	cmd.Require(flag.Min, 1)
+	utils.ParseFlags(cmd, args, true)
+
+	if *flMemoryString == "" && *flCpuShares == 0 && *flCpusetCpus == "" {
+		return fmt.Errorf("You must provide one or more flags when using this command.")
+	}
+
+	update := engine.Env{}
+	if *flMemoryString != "" {
+		memory, err := units.RAMInBytes(*flMemoryString)
+		if err != nil {
+			return err
+		}
+		update.SetInt64("Memory", memory)
+	}
+	if *flCpuShares != 0 {
+		update.SetInt64("CpuShares", *flCpuShares)
+	}
+	if *flCpusetCpus != "" {
+		update.Set("CpusetCpus", *flCpusetCpus)
+	}
+
+	var encounteredError error
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/update", name), update, nil)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			encounteredError = fmt.Errorf("Error: failed to update container named %s", name)
+		} else {
+			fmt.Fprintf(cli.out, "%s\n", name)
+		}
+	}
+	return encounteredError
+}
+
 func (cli *DockerCli) CmdRename(args ...string) error {
 	cmd := cli.Subcmd("rename", "OLD_NAME NEW_NAME", "Rename a container", true)
 	if err := cmd.Parse(args); err != nil {
@@ -1951,15 +1998,33 @@ func (cli *DockerCli) CmdDiff(args ...string) error {
 
 func (cli *DockerCli) CmdLogs(args ...string) error {
 	var (
-		cmd    = cli.Subcmd("logs", "CONTAINER", "Fetch the logs of a container", true)
-		follow = cmd.Bool([]string{"f", "-follow"}, false, "Follow log output")
-		times  = cmd.Bool([]string{"t", "-timestamps"}, false, "Show timestamps")
-		tail   = cmd.String([]string{"-tail"}, "all", "Number of lines to show from the end of the logs")
+		cmd      = cli.Subcmd("logs", "CONTAINER [CONTAINER...]", "Fetch the logs of one or more containers", true)
+		follow   = cmd.Bool([]string{"f", "-follow"}, false, "Follow log output")
+		times    = cmd.Bool([]string{"t", "-timestamps"}, false, "Show timestamps")
+		tail     = cmd.String([]string{"-tail"}, "all", "Number of lines to show from the end of the logs")
+		color    = cmd.Bool([]string{"-color"}, false, "Colorize container name prefixes of merged logs")
+		flFilter = opts.NewListOpts(nil)
 	)
-	cmd.Require(flag.Exact, 1)
+	cmd.Var(&flFilter, []string{"-filter"}, "Merge logs of containers matching a filter (label=<key> or label=<key>=<value>)")
 
 	utils.ParseFlags(cmd, args, true)
 
+	logFilterArgs := filters.Args{}
+	for _, f := range flFilter.GetAll() {
+		var err error
+		logFilterArgs, err = filters.ParseFlag(f, logFilterArgs)
+		if err != nil {
+			return err
+		}
+	}
+	if cmd.NArg() == 0 && len(logFilterArgs) == 0 {
+		cmd.Usage()
+		return nil
+	}
+	if cmd.NArg() > 1 || len(logFilterArgs) > 0 {
+		return cli.mergedLogs(cmd.Args(), logFilterArgs, *follow, *times, *color, *tail)
+	}
+
 	name := cmd.Arg(0)
 
 	stream, _, err := cli.call("GET", "/containers/"+name+"/json", nil, nil)
@@ -1992,6 +2057,79 @@ func (cli *DockerCli) CmdLogs(args ...string) error {
 	return cli.streamHelper("GET", "/containers/"+name+"/logs?"+v.Encode(), env.GetSubEnv("Config").GetBool("Tty"), nil, cli.out, cli.err, nil)
 }
 
+// logPrefixColors are the ANSI colors cycled through for container name
+// prefixes of merged logs
+var logPrefixColors = []string{"32", "33", "34", "35", "36", "92", "93", "94", "95", "96"}
+
+// mergedLogs prints the logs of several containers, merged by the daemon in
+// timestamp order, prefixing every line with the name of its container.
+func (cli *DockerCli) mergedLogs(names []string, logFilterArgs filters.Args, follow, times, color bool, tail string) error {
+	v := url.Values{}
+	v.Set("stdout", "1")
+	v.Set("stderr", "1")
+	if follow {
+		v.Set("follow", "1")
+	}
+	v.Set("tail", tail)
+	for _, name := range names {
+		v.Add("names", name)
+	}
+	if len(logFilterArgs) > 0 {
+		filterJson, err := filters.ToParam(logFilterArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("filters", filterJson)
+	}
+
+	stream, _, err := cli.call("GET", "/containers/logs?"+v.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var (
+		dec    = json.NewDecoder(stream)
+		colors = make(map[string]string)
+		width  int
+	)
+	for {
+		var rec types.ContainerLogRecord
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		// the stream starts with a record for each container, to align the
+		// prefixes of all lines to the longest name
+		if rec.Stream == "" {
+			if len(rec.Name) > width {
+				width = len(rec.Name)
+			}
+			continue
+		}
+		prefix := fmt.Sprintf("%-*s |", width, rec.Name)
+		if color {
+			c, ok := colors[rec.ID]
+			if !ok {
+				c = logPrefixColors[len(colors)%len(logPrefixColors)]
+				colors[rec.ID] = c
+			}
+			prefix = "\033[" + c + "m" + prefix + "\033[0m"
+		}
+		line := rec.Log
+		if times {
+			line = rec.Time.Format(timeutils.RFC3339NanoFixed) + " " + line
+		}
+		out := cli.out
+		if rec.Stream == "stderr" {
+			out = cli.err
+		}
+		fmt.Fprintf(out, "%s %s", prefix, line)
+	}
+}
+
 func (cli *DockerCli) CmdAttach(args ...string) error {
 	var (
 		cmd     = cli.Subcmd("attach", "CONTAINER", "Attach to a running container", true)
@@ -2397,7 +2535,7 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 			fmt.Fprintf(cli.out, "%s\n", createResponse.ID)
 		}()
 	}
-	if *flAutoRemove && (hostConfig.RestartPolicy.Name == "always" || hostConfig.RestartPolicy.Name == "on-failure") {
+	if *flAutoRemove && hostConfig.RestartPolicy.Name != "" && hostConfig.RestartPolicy.Name != "no" {
 		return ErrConflictRestartPolicyAndAutoRemove
 	}
 	// We need to instantiate the chan because the select needs it. It can
@@ -2735,22 +2873,31 @@ type containerStats struct {
 	MemoryPercentage float64
 	NetworkRx        float64
 	NetworkTx        float64
+	Pids             uint64
 	mu               sync.RWMutex
 	err              error
 }
 
-func (s *containerStats) Collect(cli *DockerCli) {
-	stream, _, err := cli.call("GET", "/containers/"+s.Name+"/stats", nil, nil)
+// Collect updates s with the stats of the container until the stream ends,
+// or with a single sample if stream is false
+func (s *containerStats) Collect(cli *DockerCli, stream bool) {
+	v := url.Values{}
+	if !stream {
+		v.Set("stream", "0")
+	}
+	body, _, err := cli.call("GET", "/containers/"+s.Name+"/stats?"+v.Encode(), nil, nil)
 	if err != nil {
+		s.mu.Lock()
 		s.err = err
+		s.mu.Unlock()
 		return
 	}
-	defer stream.Close()
+	defer body.Close()
 	var (
 		previousCpu    uint64
 		previousSystem uint64
 		start          = true
-		dec            = json.NewDecoder(stream)
+		dec            = json.NewDecoder(body)
 		u              = make(chan error, 1)
 	)
 	go func() {
@@ -2764,7 +2911,10 @@ func (s *containerStats) Collect(cli *DockerCli) {
 				memPercent = float64(v.MemoryStats.Usage) / float64(v.MemoryStats.Limit) * 100.0
 				cpuPercent = 0.0
 			)
-			if !start {
+			if v.PreCpuStats.SystemUsage != 0 {
+				// the daemon sent the cpu stats of the previous sample
+				cpuPercent = calculateCpuPercent(v.PreCpuStats.CpuUsage.TotalUsage, v.PreCpuStats.SystemUsage, v)
+			} else if !start {
 				cpuPercent = calculateCpuPercent(previousCpu, previousSystem, v)
 			}
 			start = false
@@ -2775,15 +2925,23 @@ func (s *containerStats) Collect(cli *DockerCli) {
 			s.MemoryPercentage = memPercent
 			s.NetworkRx = float64(v.Network.RxBytes)
 			s.NetworkTx = float64(v.Network.TxBytes)
+			s.Pids = v.PidsStats.Current
 			s.mu.Unlock()
 			previousCpu = v.CpuStats.CpuUsage.TotalUsage
 			previousSystem = v.CpuStats.SystemUsage
 			u <- nil
+			if !stream {
+				return
+			}
 		}
 	}()
 	for {
 		select {
 		case <-time.After(2 * time.Second):
+			if !stream {
+				// the daemon needs two samples, which are a second apart
+				continue
+			}
 			// zero out the values if we have not received an update within
 			// the specified duration.
 			s.mu.Lock()
@@ -2798,46 +2956,157 @@ func (s *containerStats) Collect(cli *DockerCli) {
 				s.mu.Unlock()
 				return
 			}
+			if !stream {
+				return
+			}
 		}
 	}
 }
 
-func (s *containerStats) Display(w io.Writer) error {
+// CPUPerc, MemUsage, MemPerc, NetIO and PIDs return the columns of the default
+// output, for use in --format templates
+
+func (s *containerStats) CPUPerc() string {
+	return fmt.Sprintf("%.2f%%", s.CpuPercentage)
+}
+
+func (s *containerStats) MemUsage() string {
+	return fmt.Sprintf("%s/%s", units.BytesSize(s.Memory), units.BytesSize(s.MemoryLimit))
+}
+
+func (s *containerStats) MemPerc() string {
+	return fmt.Sprintf("%.2f%%", s.MemoryPercentage)
+}
+
+func (s *containerStats) NetIO() string {
+	return fmt.Sprintf("%s/%s", units.BytesSize(s.NetworkRx), units.BytesSize(s.NetworkTx))
+}
+
+func (s *containerStats) PIDs() string {
+	return strconv.FormatUint(s.Pids, 10)
+}
+
+// Display writes a line with the stats of s, rendered by tmpl if it isn't
+// nil
+func (s *containerStats) Display(w io.Writer, tmpl *template.Template) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if s.err != nil {
 		return s.err
 	}
-	fmt.Fprintf(w, "%s\t%.2f%%\t%s/%s\t%.2f%%\t%s/%s\n",
-		s.Name,
-		s.CpuPercentage,
-		units.BytesSize(s.Memory), units.BytesSize(s.MemoryLimit),
-		s.MemoryPercentage,
-		units.BytesSize(s.NetworkRx), units.BytesSize(s.NetworkTx))
+	if tmpl != nil {
+		if err := tmpl.Execute(w, s); err != nil {
+			return err
+		}
+		_, err := w.Write([]byte{'\n'})
+		return err
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, s.CPUPerc(), s.MemUsage(), s.MemPerc(), s.NetIO(), s.PIDs())
 	return nil
 }
 
+// runningContainerNames returns the names of all running containers
+func (cli *DockerCli) runningContainerNames() ([]string, error) {
+	body, _, err := readBody(cli.call("GET", "/containers/json", nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	outs := engine.NewTable("", 0)
+	if _, err := outs.ReadListFrom(body); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, out := range outs.Data {
+		if n := out.GetList("Names"); len(n) > 0 {
+			names = append(names, strings.TrimPrefix(n[0], "/"))
+		} else {
+			names = append(names, out.Get("Id"))
+		}
+	}
+	return names, nil
+}
+
 func (cli *DockerCli) CmdStats(args ...string) error {
-	cmd := cli.Subcmd("stats", "CONTAINER [CONTAINER...]", "Display a live stream of one or more containers' resource usage statistics", true)
-	cmd.Require(flag.Min, 1)
+	cmd := cli.Subcmd("stats", "[CONTAINER...]", "Display a live stream of one or more containers' resource usage statistics", true)
+	all := cmd.Bool([]string{"a", "-all"}, false, "Show all running containers")
+	noStream := cmd.Bool([]string{"-no-stream"}, false, "Print a single sample and exit")
+	tmplStr := cmd.String([]string{"-format"}, "", "Format the output using the given go template")
 	utils.ParseFlags(cmd, args, true)
 
 	names := cmd.Args()
+	if *all {
+		running, err := cli.runningContainerNames()
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		for _, n := range names {
+			seen[n] = true
+		}
+		for _, n := range running {
+			if !seen[n] {
+				names = append(names, n)
+			}
+		}
+	} else if len(names) == 0 {
+		cmd.Usage()
+		return nil
+	}
 	sort.Strings(names)
+
+	var tmpl *template.Template
+	if *tmplStr != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*tmplStr); err != nil {
+			fmt.Fprintf(cli.err, "Template parsing error: %v\n", err)
+			return &utils.StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
+
 	var (
 		cStats []*containerStats
 		w      = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+		wg     sync.WaitGroup
 	)
 	printHeader := func() {
-		fmt.Fprint(cli.out, "\033[2J")
-		fmt.Fprint(cli.out, "\033[H")
-		fmt.Fprintln(w, "CONTAINER\tCPU %\tMEM USAGE/LIMIT\tMEM %\tNET I/O")
+		if !*noStream {
+			fmt.Fprint(cli.out, "\033[2J")
+			fmt.Fprint(cli.out, "\033[H")
+		}
+		if tmpl == nil {
+			fmt.Fprintln(w, "CONTAINER\tCPU %\tMEM USAGE/LIMIT\tMEM %\tNET I/O\tPIDS")
+		}
 	}
 	for _, n := range names {
 		s := &containerStats{Name: n}
 		cStats = append(cStats, s)
-		go s.Collect(cli)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Collect(cli, !*noStream)
+		}()
+	}
+
+	if *noStream {
+		wg.Wait()
+		var errs []string
+		printHeader()
+		for _, s := range cStats {
+			if err := s.Display(w, tmpl); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", s.Name, err))
+			}
+		}
+		w.Flush()
+		if len(errs) > 0 {
+			return fmt.Errorf("%s", strings.Join(errs, ", "))
+		}
+		return nil
+	}
+	if len(cStats) == 0 {
+		return nil
 	}
+
 	// do a quick pause so that any failed connections for containers that do not exist are able to be
 	// evicted before we display the initial or default values.
 	time.Sleep(500 * time.Millisecond)
@@ -2856,7 +3125,7 @@ func (cli *DockerCli) CmdStats(args ...string) error {
 		printHeader()
 		toRemove := []int{}
 		for i, s := range cStats {
-			if err := s.Display(w); err != nil {
+			if err := s.Display(w, tmpl); err != nil {
 				toRemove = append(toRemove, i)
 			}
 		}
@@ -16,6 +16,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"crypto/tls"
 	"crypto/x509"
@@ -30,6 +31,7 @@ import (
 	"github.com/docker/docker/daemon/networkdriver/bridge"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/listenbuffer"
+	"github.com/docker/docker/pkg/metrics"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/version"
@@ -252,6 +254,27 @@ func postContainersUnpause(eng *engine.Engine, version version.Version, w http.R
 	return nil
 }
 
+func postContainersUpdate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	job := eng.Job("update", vars["name"])
+	if err := job.DecodeEnv(r.Body); err != nil {
+		return err
+	}
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func getContainersExport(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -338,6 +361,9 @@ func getEvents(eng *engine.Engine, version version.Version, w http.ResponseWrite
 	job.Setenv("since", r.Form.Get("since"))
 	job.Setenv("until", r.Form.Get("until"))
 	job.Setenv("filters", r.Form.Get("filters"))
+	// older clients only know the status, id, from and time of container
+	// and image events
+	job.SetenvBool("legacy", version.LessThan("1.18"))
 	return job.Run()
 }
 
@@ -429,10 +455,30 @@ func getContainersStats(eng *engine.Engine, version version.Version, w http.Resp
 	}
 	name := vars["name"]
 	job := eng.Job("container_stats", name)
+	if r.Form.Get("stream") != "" {
+		stream, err := getBoolParam(r.Form.Get("stream"))
+		if err != nil {
+			return err
+		}
+		job.SetenvBool("stream", stream)
+	}
 	streamJSON(job, w, true)
 	return job.Run()
 }
 
+func getContainersStatsHistory(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	job := eng.Job("container_stats_history", vars["name"])
+	job.Setenv("since", r.Form.Get("since"))
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
 func getContainersLogs(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -481,6 +527,40 @@ func getContainersLogs(eng *engine.Engine, version version.Version, w http.Respo
 	return nil
 }
 
+func getContainersLogsMulti(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	job := eng.Job("containers_logs", r.Form["names"]...)
+	job.Setenv("filters", r.Form.Get("filters"))
+	job.Setenv("follow", r.Form.Get("follow"))
+	job.Setenv("tail", r.Form.Get("tail"))
+	job.Setenv("stdout", r.Form.Get("stdout"))
+	job.Setenv("stderr", r.Form.Get("stderr"))
+	// Validate args here, because we can't return not StatusOK after job.Run() call
+	if !(job.GetenvBool("stdout") || job.GetenvBool("stderr")) {
+		return fmt.Errorf("Bad parameters: you must choose at least one stream")
+	}
+	streamJSON(job, w, true)
+
+	// the followed logs are only written when new lines are logged, stop
+	// following them as soon as the client disconnects
+	if closeNotifier, ok := w.(http.CloseNotifier); ok {
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-finished:
+			case <-closeNotifier.CloseNotify():
+				log.Debugf("Client disconnected, cancelling job: %v", job)
+				job.Cancel()
+			}
+		}()
+	}
+	return job.Run()
+}
+
 func postImagesTag(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -1253,6 +1333,22 @@ func writeCorsHeaders(w http.ResponseWriter, r *http.Request, corsHeaders string
 	w.Header().Add("Access-Control-Allow-Methods", "GET, POST, DELETE, PUT, OPTIONS")
 }
 
+// apiRequestDuration holds the latencies of API requests by method and route
+var apiRequestDuration = metrics.NewHistogramVec(metrics.DefaultBuckets, "method", "route")
+
+func getMetrics(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	job := eng.Job("metrics")
+	job.Stdout.Add(w)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	mw := metrics.NewWriter(w)
+	mw.Family("docker_api_request_duration_seconds", metrics.TypeHistogram, "Latency of remote API requests by method and route.")
+	apiRequestDuration.Write(mw, "docker_api_request_duration_seconds")
+	return mw.Err()
+}
+
 func ping(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	_, err := w.Write([]byte{'O', 'K'})
 	return err
@@ -1286,6 +1382,10 @@ func makeHttpHandler(eng *engine.Engine, logging bool, localMethod string, local
 			return
 		}
 
+		start := time.Now()
+		defer func() {
+			apiRequestDuration.With(localMethod, localRoute).Observe(time.Since(start).Seconds())
+		}()
 		if err := handlerFunc(eng, version, w, r, mux.Vars(r)); err != nil {
 			log.Errorf("Handler for %s %s returned error: %s", localMethod, localRoute, err)
 			httpError(w, err)
@@ -1328,28 +1428,31 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 	}
 	m := map[string]map[string]HttpApiFunc{
 		"GET": {
-			"/_ping":                          ping,
-			"/events":                         getEvents,
-			"/info":                           getInfo,
-			"/version":                        getVersion,
-			"/images/json":                    getImagesJSON,
-			"/images/viz":                     getImagesViz,
-			"/images/search":                  getImagesSearch,
-			"/images/get":                     getImagesGet,
-			"/images/{name:.*}/get":           getImagesGet,
-			"/images/{name:.*}/history":       getImagesHistory,
-			"/images/{name:.*}/json":          getImagesByName,
-			"/containers/ps":                  getContainersJSON,
-			"/containers/json":                getContainersJSON,
-			"/containers/{name:.*}/export":    getContainersExport,
-			"/containers/{name:.*}/changes":   getContainersChanges,
-			"/containers/{name:.*}/json":      getContainersByName,
-			"/containers/{name:.*}/top":       getContainersTop,
-			"/containers/{name:.*}/logs":      getContainersLogs,
-			"/containers/{name:.*}/stats":     getContainersStats,
-			"/containers/{name:.*}/attach/ws": wsContainersAttach,
-			"/exec/{id:.*}/json":              getExecByID,
-			"/ip/print":                       getPrintIP,
+			"/_ping":                              ping,
+			"/metrics":                            getMetrics,
+			"/events":                             getEvents,
+			"/info":                               getInfo,
+			"/version":                            getVersion,
+			"/images/json":                        getImagesJSON,
+			"/images/viz":                         getImagesViz,
+			"/images/search":                      getImagesSearch,
+			"/images/get":                         getImagesGet,
+			"/images/{name:.*}/get":               getImagesGet,
+			"/images/{name:.*}/history":           getImagesHistory,
+			"/images/{name:.*}/json":              getImagesByName,
+			"/containers/ps":                      getContainersJSON,
+			"/containers/json":                    getContainersJSON,
+			"/containers/logs":                    getContainersLogsMulti,
+			"/containers/{name:.*}/export":        getContainersExport,
+			"/containers/{name:.*}/changes":       getContainersChanges,
+			"/containers/{name:.*}/json":          getContainersByName,
+			"/containers/{name:.*}/top":           getContainersTop,
+			"/containers/{name:.*}/logs":          getContainersLogs,
+			"/containers/{name:.*}/stats":         getContainersStats,
+			"/containers/{name:.*}/stats/history": getContainersStatsHistory,
+			"/containers/{name:.*}/attach/ws":     wsContainersAttach,
+			"/exec/{id:.*}/json":                  getExecByID,
+			"/ip/print":                           getPrintIP,
 		},
 		"POST": {
 			"/auth":                         postAuth,
@@ -1363,6 +1466,7 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/containers/{name:.*}/kill":    postContainersKill,
 			"/containers/{name:.*}/pause":   postContainersPause,
 			"/containers/{name:.*}/unpause": postContainersUnpause,
+			"/containers/{name:.*}/update":  postContainersUpdate,
 			"/containers/{name:.*}/restart": postContainersRestart,
 			"/containers/{name:.*}/start":   postContainersStart,
 			"/containers/{name:.*}/stop":    postContainersStop,
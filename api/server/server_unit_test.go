@@ -116,6 +116,27 @@ func TestGetInfo(t *testing.T) {
 	assertContentType(r, "application/json", t)
 }
 
+func TestGetMetrics(t *testing.T) {
+	eng := engine.New()
+	eng.Register("metrics", func(job *engine.Job) engine.Status {
+		fmt.Fprintf(job.Stdout, "# HELP docker_images Number of image layers.\n# TYPE docker_images gauge\ndocker_images 3\n")
+		return engine.StatusOK
+	})
+	eng.Register("info", func(job *engine.Job) engine.Status {
+		return engine.StatusOK
+	})
+	serveRequest("GET", "/info", nil, eng, t)
+	r := serveRequest("GET", "/metrics", nil, eng, t)
+	assertContentType(r, "text/plain; version=0.0.4", t)
+	body := r.Body.String()
+	if !strings.Contains(body, "docker_images 3\n") {
+		t.Fatalf("Daemon metrics missing from %q", body)
+	}
+	if !strings.Contains(body, `docker_api_request_duration_seconds_count{method="GET",route="/info"}`) {
+		t.Fatalf("API latency of /info missing from %q", body)
+	}
+}
+
 func TestGetImagesJSON(t *testing.T) {
 	eng := engine.New()
 	var called bool
@@ -250,6 +271,28 @@ func TestGetContainersByName(t *testing.T) {
 	}
 }
 
+func TestGetContainersStatsNoStream(t *testing.T) {
+	eng := engine.New()
+	var streams []string
+	eng.Register("container_stats", func(job *engine.Job) engine.Status {
+		if !job.EnvExists("stream") {
+			streams = append(streams, "")
+		} else {
+			streams = append(streams, job.Getenv("stream"))
+		}
+		return engine.StatusOK
+	})
+	for _, target := range []string{"/containers/foo/stats", "/containers/foo/stats?stream=0", "/containers/foo/stats?stream=1"} {
+		if r := serveRequest("GET", target, nil, eng, t); r.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, expected %d", target, r.Code, http.StatusOK)
+		}
+	}
+	expected := []string{"", "0", "1"}
+	if !reflect.DeepEqual(streams, expected) {
+		t.Fatalf("Expected stream envs %v, got %v", expected, streams)
+	}
+}
+
 func TestGetEvents(t *testing.T) {
 	eng := engine.New()
 	var called bool
@@ -46,6 +46,17 @@ type MemoryStats struct {
 	// number of times memory usage hits limits.
 	Failcnt uint64 `json:"failcnt"`
 	Limit   uint64 `json:"limit"`
+	// anonymous memory and swap cache, from memory.stat.
+	Rss uint64 `json:"rss"`
+	// page cache, from memory.stat.
+	Cache uint64 `json:"cache"`
+	// swap used, from memory.stat if swap accounting is enabled.
+	Swap uint64 `json:"swap"`
+	// page cache mapped into processes, from memory.stat.
+	MappedFile uint64 `json:"mapped_file"`
+	// number of processes killed by the OOM killer, 0 on kernels which do
+	// not report it.
+	OomKills uint64 `json:"oom_kills"`
 }
 
 type BlkioStatEntry struct {
@@ -67,6 +78,14 @@ type BlkioStats struct {
 	SectorsRecursive        []BlkioStatEntry `json:"sectors_recursive"`
 }
 
+type PidsStats struct {
+	// number of processes in the container, or of tasks where the kernel
+	// has a pids cgroup.
+	Current uint64 `json:"current"`
+	// maximum number of tasks in the container, 0 if there is no limit.
+	Limit uint64 `json:"limit,omitempty"`
+}
+
 type Network struct {
 	RxBytes   uint64 `json:"rx_bytes"`
 	RxPackets uint64 `json:"rx_packets"`
@@ -82,6 +101,8 @@ type Stats struct {
 	Read        time.Time   `json:"read"`
 	Network     Network     `json:"network,omitempty"`
 	CpuStats    CpuStats    `json:"cpu_stats,omitempty"`
+	PreCpuStats CpuStats    `json:"precpu_stats,omitempty"`
 	MemoryStats MemoryStats `json:"memory_stats,omitempty"`
 	BlkioStats  BlkioStats  `json:"blkio_stats,omitempty"`
+	PidsStats   PidsStats   `json:"pids_stats,omitempty"`
 }
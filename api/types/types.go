@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // ContainerCreateResponse contains the information returned to a client on the
 // creation of a new container.
 type ContainerCreateResponse struct {
@@ -9,3 +11,68 @@ type ContainerCreateResponse struct {
 	// Warnings are any warnings encountered during the creation of the container.
 	Warnings []string `json:"Warnings"`
 }
+
+// ContainerLogRecord is a single log line in the merged log stream of several
+// containers.
+type ContainerLogRecord struct {
+	// ID is the ID of the container which produced the line.
+	ID string `json:"id"`
+
+	// Name is the name of the container which produced the line.
+	Name string `json:"name"`
+
+	// Stream is either "stdout" or "stderr".
+	Stream string `json:"stream"`
+
+	// Log is the line itself including the trailing newline.
+	Log string `json:"log"`
+
+	// Time is the time the line was logged.
+	Time time.Time `json:"time"`
+}
+
+// Types of objects which produce events.
+const (
+	ContainerEventType = "container"
+	ImageEventType     = "image"
+	NetworkEventType   = "network"
+	IPEventType        = "ip"
+	VolumeEventType    = "volume"
+)
+
+// EventActor describes the object an event happened to.
+type EventActor struct {
+	// ID is the ID of the container, image, IP address or other object.
+	ID string
+
+	// Attributes holds details of the event, e.g. container name, exit code
+	// or signal.
+	Attributes map[string]string
+
+	// Labels holds the labels of the container or image, as they were at
+	// the time of the event.
+	Labels map[string]string `json:",omitempty"`
+}
+
+// Event is a single message of the daemon events stream.
+type Event struct {
+	// Status, ID and From are the legacy event fields, kept for clients which
+	// only know them. Status is the action, ID the container or image ID and
+	// From the image of a container.
+	Status string `json:"status,omitempty"`
+	ID     string `json:"id,omitempty"`
+	From   string `json:"from,omitempty"`
+
+	// Type is the type of the object the event happened to, one of the
+	// *EventType constants.
+	Type string `json:"Type,omitempty"`
+
+	// Action is what happened, e.g. "start", "die" or "untag".
+	Action string `json:"Action,omitempty"`
+
+	// Actor is the object the event happened to.
+	Actor EventActor `json:"Actor"`
+
+	// Time is the Unix time of the event.
+	Time int64 `json:"time,omitempty"`
+}
@@ -439,6 +439,60 @@ func volume(b *Builder, args []string, attributes map[string]bool, original stri
 	return nil
 }
 
+// HEALTHCHECK [--interval=30s] [--timeout=30s] [--retries=3] CMD command
+// HEALTHCHECK NONE
+//
+// Set the command run to check the health of containers of the image, in the
+// forms of CMD, or disable the health check of the base image.
+//
+func healthcheck(b *Builder, args []string, attributes map[string]bool, original string) error {
+	var i int
+	for i < len(args) && strings.HasPrefix(args[i], "--") {
+		i++
+	}
+	options, args := args[:i], args[i:]
+	if len(args) == 0 {
+		return fmt.Errorf("HEALTHCHECK requires CMD or NONE")
+	}
+
+	if args[0] == "NONE" {
+		if len(options) > 0 {
+			return fmt.Errorf("HEALTHCHECK NONE takes no options")
+		}
+		b.Config.Healthcheck = &runconfig.HealthConfig{Test: []string{"NONE"}}
+		return b.commit("", b.Config.Cmd, "HEALTHCHECK NONE")
+	}
+
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.Usage = nil
+	var (
+		interval = flags.Duration([]string{"-interval"}, 0, "Time between running the check")
+		timeout  = flags.Duration([]string{"-timeout"}, 0, "Maximum time to allow one check to run")
+		retries  = flags.Int([]string{"-retries"}, 0, "Consecutive failures needed to report unhealthy")
+	)
+	if err := flags.Parse(options); err != nil {
+		return fmt.Errorf("HEALTHCHECK: %s", err)
+	}
+	if *interval < 0 || *timeout < 0 || *retries < 0 {
+		return fmt.Errorf("HEALTHCHECK options can't be negative")
+	}
+
+	healthConfig := &runconfig.HealthConfig{
+		Interval: *interval,
+		Timeout:  *timeout,
+		Retries:  *retries,
+	}
+	cmd := handleJsonArgs(args[1:], attributes)
+	if attributes["json"] {
+		healthConfig.Test = append([]string{"CMD"}, cmd...)
+	} else {
+		healthConfig.Test = []string{"CMD-SHELL", cmd[0]}
+	}
+	b.Config.Healthcheck = healthConfig
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("HEALTHCHECK %q", healthConfig.Test))
+}
+
 // INSERT is no longer accepted, but we still parse it.
 func insert(b *Builder, args []string, attributes map[string]bool, original string) error {
 	return fmt.Errorf("INSERT has been deprecated. Please use ADD instead")
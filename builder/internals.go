@@ -26,6 +26,7 @@ import (
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/common"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/progressreader"
@@ -652,6 +653,11 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		destPath = destPath + "/"
 	}
 
+	// The files are owned by the root of the container, which is not the
+	// root of the host when the daemon remaps it
+	rootUID, rootGID := b.Daemon.GetRemappedUIDGID()
+	archiver := chrootarchive.NewArchiver(b.Daemon.GetUIDGIDMaps())
+
 	destStat, err := os.Stat(destPath)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -669,7 +675,7 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 	}
 
 	if fi.IsDir() {
-		return copyAsDirectory(origPath, destPath, destExists)
+		return copyAsDirectory(archiver, origPath, destPath, rootUID, rootGID, destExists)
 	}
 
 	// If we are adding a remote file (or we've been told not to decompress), do not try to untar it
@@ -684,17 +690,17 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		}
 
 		// try to successfully untar the orig
-		if err := chrootarchive.UntarPath(origPath, tarDest); err == nil {
+		if err := archiver.UntarPath(origPath, tarDest); err == nil {
 			return nil
 		} else if err != io.EOF {
 			log.Debugf("Couldn't untar %s to %s: %s", origPath, tarDest, err)
 		}
 	}
 
-	if err := os.MkdirAll(path.Dir(destPath), 0755); err != nil {
+	if err := idtools.MkdirAllAs(path.Dir(destPath), 0755, rootUID, rootGID); err != nil {
 		return err
 	}
-	if err := chrootarchive.CopyWithTar(origPath, destPath); err != nil {
+	if err := archiver.CopyWithTar(origPath, destPath); err != nil {
 		return err
 	}
 
@@ -703,14 +709,14 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		resPath = path.Join(destPath, path.Base(origPath))
 	}
 
-	return fixPermissions(origPath, resPath, 0, 0, destExists)
+	return fixPermissions(origPath, resPath, rootUID, rootGID, destExists)
 }
 
-func copyAsDirectory(source, destination string, destExisted bool) error {
-	if err := chrootarchive.CopyWithTar(source, destination); err != nil {
+func copyAsDirectory(archiver *archive.Archiver, source, destination string, uid, gid int, destExisted bool) error {
+	if err := archiver.CopyWithTar(source, destination); err != nil {
 		return err
 	}
-	return fixPermissions(source, destination, 0, 0, destExisted)
+	return fixPermissions(source, destination, uid, gid, destExisted)
 }
 
 func fixPermissions(source, destination string, uid, gid int, destExisted bool) error {
@@ -292,3 +292,61 @@ func parseMaybeJSONToList(rest string) (*Node, map[string]bool, error) {
 
 	return parseStringsWhitespaceDelimited(rest)
 }
+
+// parseHealthcheck parses HEALTHCHECK [--OPTION=VALUE...] CMD command, where
+// command has the forms of CMD, and HEALTHCHECK NONE. The options are the
+// first nodes:
+//
+// HEALTHCHECK --interval=5s CMD ["curl", "-f", "http://localhost/"] ->
+// (healthcheck "--interval=5s" "CMD" "curl" "-f" "http://localhost/")
+//
+func parseHealthcheck(rest string) (*Node, map[string]bool, error) {
+	var top, prev *Node
+	add := func(node *Node) {
+		if prev == nil {
+			top = node
+		} else {
+			prev.Next = node
+		}
+		prev = node
+	}
+
+	rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	for strings.HasPrefix(rest, "--") {
+		fields := TOKEN_WHITESPACE.Split(rest, 2)
+		add(&Node{Value: fields[0]})
+		rest = ""
+		if len(fields) == 2 {
+			rest = fields[1]
+		}
+	}
+
+	fields := TOKEN_WHITESPACE.Split(rest, 2)
+	args := ""
+	if len(fields) == 2 {
+		args = strings.TrimSpace(fields[1])
+	}
+	switch typ := strings.ToUpper(fields[0]); typ {
+	case "NONE":
+		if args != "" {
+			return nil, nil, fmt.Errorf("HEALTHCHECK NONE takes no arguments")
+		}
+		add(&Node{Value: typ})
+		return top, nil, nil
+	case "CMD":
+		cmd, attrs, err := parseMaybeJSON(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cmd == nil {
+			return nil, nil, fmt.Errorf("Missing command after HEALTHCHECK CMD")
+		}
+		add(&Node{Value: typ})
+		prev.Next = cmd
+		return top, attrs, nil
+	case "":
+		return nil, nil, fmt.Errorf("HEALTHCHECK requires CMD or NONE")
+	default:
+		return nil, nil, fmt.Errorf("Unknown type %q in HEALTHCHECK (try CMD)", typ)
+	}
+}
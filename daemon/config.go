@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"net"
+	"time"
 
 	"github.com/docker/docker/daemon/networkdriver"
 	"github.com/docker/docker/opts"
@@ -53,6 +54,13 @@ type Config struct {
 	Ulimits                     map[string]*ulimit.Ulimit
 	LogConfig                   runconfig.LogConfig
 	HostIface                   string
+	EventsJournalMaxSize        int64
+	EventsWebhooks              []string
+	StatsHistory                time.Duration
+	MetricsPush                 string
+	MetricsPushPrefix           string
+	MetricsPushInterval         time.Duration
+	RemappedRoot                string
 }
 
 // InstallFlags adds command-line options to the top-level flag parser for
@@ -91,7 +99,16 @@ func (config *Config) InstallFlags() {
 	config.Ulimits = make(map[string]*ulimit.Ulimit)
 	opts.UlimitMapVar(config.Ulimits, []string{"-default-ulimit"}, "Set default ulimits for containers")
 	flag.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", "Containers logging driver")
+	config.LogConfig.Config = make(map[string]string)
+	opts.LogOptsVar(config.LogConfig.Config, []string{"-log-opt"}, "Set log driver options")
 	flag.StringVar(&config.HostIface, []string{"-host-iface"}, "", "Select the host network interface to use")
+	flag.Int64Var(&config.EventsJournalMaxSize, []string{"-events-journal-max-size"}, 16*1024*1024, "Maximum size in bytes of the on-disk events journal, 0 disables it")
+	opts.ListVar(&config.EventsWebhooks, []string{"-events-webhook"}, "Send events to an HTTP endpoint, as URL[,KEY=VALUE...] with optional event filters")
+	flag.DurationVar(&config.StatsHistory, []string{"-stats-history"}, 0, "Duration of the resource usage stats history kept for each running container, 0 disables it")
+	flag.StringVar(&config.MetricsPush, []string{"-metrics-push"}, "", "Push metrics to a StatsD server at statsd://HOST:PORT, or a Graphite server at graphite://HOST:PORT")
+	flag.StringVar(&config.MetricsPushPrefix, []string{"-metrics-push-prefix"}, "docker.{{.Name}}", "Go template of the prefix of pushed metrics, given the container .ID, .Name, .Image and .Labels")
+	flag.DurationVar(&config.MetricsPushInterval, []string{"-metrics-push-interval"}, 10*time.Second, "Interval between metrics pushes")
+	flag.StringVar(&config.RemappedRoot, []string{"-userns-remap"}, "", "Run containers in a user namespace whose root is the subordinate ids of 'default', USER, USER:GROUP, UID or UID:GID")
 }
 
 func getDefaultNetworkMtu() int {
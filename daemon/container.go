@@ -20,10 +20,10 @@ import (
 	"github.com/docker/libcontainer/label"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
-	"github.com/docker/docker/daemon/logger/syslog"
 	"github.com/docker/docker/daemon/networkdriver/bridge"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/image"
@@ -90,7 +90,11 @@ type Container struct {
 	daemon                   *Daemon
 	MountLabel, ProcessLabel string
 	AppArmorProfile          string
+	SeccompProfile           string // "default", "unconfined" or the JSON of a custom profile
+	NoNewPrivileges          bool
 	RestartCount             int
+	RestartReason            string // Why the container was last restarted by its restart policy
+	HasBeenManuallyStopped   bool   // Whether the container was stopped by the user, for the unless-stopped restart policy
 	UpdateDns                bool
 
 	// Maps container paths to volume paths.  The key in this is the path to which
@@ -200,8 +204,28 @@ func (container *Container) WriteHostConfig() error {
 }
 
 func (container *Container) LogEvent(action string) {
+	container.LogEventWithAttributes(action, nil)
+}
+
+// LogEventWithAttributes logs a container event. Attributes of the event are
+// the container name and image, and the given extra attributes, e.g. the exit
+// code of a "die" event. The container labels are logged with it.
+func (container *Container) LogEventWithAttributes(action string, extra map[string]string) {
 	d := container.daemon
-	if err := d.eng.Job("log", action, container.ID, d.Repositories().ImageName(container.ImageID)).Run(); err != nil {
+	image := d.Repositories().ImageName(container.ImageID)
+	attributes := make(map[string]string)
+	for k, v := range extra {
+		attributes[k] = v
+	}
+	attributes["name"] = strings.TrimPrefix(container.Name, "/")
+	attributes["image"] = image
+	job := d.eng.Job("log", action, container.ID, image)
+	job.Setenv("Type", types.ContainerEventType)
+	job.SetenvJson("Attributes", attributes)
+	if container.Config != nil && len(container.Config.Labels) > 0 {
+		job.SetenvJson("Labels", container.Config.Labels)
+	}
+	if err := job.Run(); err != nil {
 		log.Errorf("Error logging event %s for %s: %s", action, container.ID, err)
 	}
 }
@@ -309,12 +333,40 @@ func populateCommand(c *Container, env []string) error {
 		rlimits = append(rlimits, rl)
 	}
 
+	readBps, err := getThrottleDevices(c.hostConfig.BlkioDeviceReadBps)
+	if err != nil {
+		return err
+	}
+	writeBps, err := getThrottleDevices(c.hostConfig.BlkioDeviceWriteBps)
+	if err != nil {
+		return err
+	}
+	readIOps, err := getThrottleDevices(c.hostConfig.BlkioDeviceReadIOps)
+	if err != nil {
+		return err
+	}
+	writeIOps, err := getThrottleDevices(c.hostConfig.BlkioDeviceWriteIOps)
+	if err != nil {
+		return err
+	}
+
 	resources := &execdriver.Resources{
-		Memory:     c.hostConfig.Memory,
-		MemorySwap: c.hostConfig.MemorySwap,
-		CpuShares:  c.hostConfig.CpuShares,
-		CpusetCpus: c.hostConfig.CpusetCpus,
-		Rlimits:    rlimits,
+		Memory:               c.hostConfig.Memory,
+		MemorySwap:           c.hostConfig.MemorySwap,
+		MemoryReservation:    c.hostConfig.MemoryReservation,
+		KernelMemory:         c.hostConfig.KernelMemory,
+		OomKillDisable:       c.hostConfig.OomKillDisable,
+		PidsLimit:            c.hostConfig.PidsLimit,
+		CpuShares:            c.hostConfig.CpuShares,
+		CpusetCpus:           c.hostConfig.CpusetCpus,
+		CpuPeriod:            c.hostConfig.CpuPeriod,
+		CpuQuota:             c.hostConfig.CpuQuota,
+		BlkioWeight:          c.hostConfig.BlkioWeight,
+		BlkioDeviceReadBps:   readBps,
+		BlkioDeviceWriteBps:  writeBps,
+		BlkioDeviceReadIOps:  readIOps,
+		BlkioDeviceWriteIOps: writeIOps,
+		Rlimits:              rlimits,
 	}
 
 	processConfig := execdriver.ProcessConfig{
@@ -333,6 +385,7 @@ func populateCommand(c *Container, env []string) error {
 		Rootfs:             c.RootfsPath(),
 		ReadonlyRootfs:     c.hostConfig.ReadonlyRootfs,
 		InitPath:           "/.dockerinit",
+		Init:               c.hostConfig.Init,
 		WorkingDir:         c.Config.WorkingDir,
 		Network:            en,
 		Ipc:                ipc,
@@ -347,12 +400,35 @@ func populateCommand(c *Container, env []string) error {
 		MountLabel:         c.GetMountLabel(),
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
+		SeccompProfile:     c.SeccompProfile,
+		NoNewPrivileges:    c.NoNewPrivileges,
 		CgroupParent:       c.hostConfig.CgroupParent,
+		Sysctls:            c.hostConfig.Sysctls,
+		Tmpfs:              c.hostConfig.Tmpfs,
+		UIDMapping:         c.daemon.uidMaps,
+		GIDMapping:         c.daemon.gidMaps,
 	}
 
 	return nil
 }
 
+// getThrottleDevices resolves the paths of the rate limited devices to their
+// device numbers
+func getThrottleDevices(throttleDevices []runconfig.ThrottleDevice) ([]*configs.ThrottleDevice, error) {
+	var out []*configs.ThrottleDevice
+	for _, td := range throttleDevices {
+		device, err := devices.DeviceFromPath(td.Path, "rwm")
+		if err != nil {
+			return nil, fmt.Errorf("error gathering device information while limiting the rate of device %q: %s", td.Path, err)
+		}
+		if device.Type != 'b' {
+			return nil, fmt.Errorf("%s is not a block device", td.Path)
+		}
+		out = append(out, &configs.ThrottleDevice{Major: device.Major, Minor: device.Minor, Rate: td.Rate})
+	}
+	return out, nil
+}
+
 func (container *Container) Start() (err error) {
 	container.Lock()
 	defer container.Unlock()
@@ -391,6 +467,9 @@ func (container *Container) Start() (err error) {
 	if err := container.updateParentsHosts(); err != nil {
 		return err
 	}
+	if err := container.chownNetworkFiles(); err != nil {
+		return err
+	}
 	container.verifyDaemonSettings()
 	if err := container.prepareVolumes(); err != nil {
 		return err
@@ -754,6 +833,15 @@ func (container *Container) Unpause() error {
 	return container.daemon.Unpause(container)
 }
 
+// setManuallyStopped records whether the container was stopped by the user,
+// the daemon doesn't start such a container with the unless-stopped restart
+// policy when it starts
+func (container *Container) setManuallyStopped(stopped bool) {
+	container.Lock()
+	container.HasBeenManuallyStopped = stopped
+	container.Unlock()
+}
+
 func (container *Container) Kill() error {
 	if !container.IsRunning() {
 		return nil
@@ -853,7 +941,11 @@ func (container *Container) Export() (archive.Archive, error) {
 		return nil, err
 	}
 
-	archive, err := archive.Tar(container.basefs, archive.Uncompressed)
+	archive, err := archive.TarWithOptions(container.basefs, &archive.TarOptions{
+		Compression: archive.Uncompressed,
+		UIDMaps:     container.daemon.uidMaps,
+		GIDMaps:     container.daemon.gidMaps,
+	})
 	if err != nil {
 		container.Unmount()
 		return nil, err
@@ -991,6 +1083,8 @@ func (container *Container) Copy(resource string) (io.ReadCloser, error) {
 	archive, err := archive.TarWithOptions(basePath, &archive.TarOptions{
 		Compression:  archive.Uncompressed,
 		IncludeFiles: filter,
+		UIDMaps:      container.daemon.uidMaps,
+		GIDMaps:      container.daemon.gidMaps,
 	})
 	if err != nil {
 		container.Unmount()
@@ -1214,6 +1308,25 @@ func (container *Container) updateParentsHosts() error {
 	return nil
 }
 
+// chownNetworkFiles gives the hostname, hosts and resolv.conf files of the
+// container to its root, which is not the root of the host when the daemon
+// runs with --userns-remap
+func (container *Container) chownNetworkFiles() error {
+	rootUID, rootGID := container.daemon.GetRemappedUIDGID()
+	if rootUID == 0 && rootGID == 0 {
+		return nil
+	}
+	for _, p := range []string{container.HostnamePath, container.HostsPath, container.ResolvConfPath} {
+		if p == "" {
+			continue
+		}
+		if err := os.Lchown(p, rootUID, rootGID); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 func (container *Container) initializeNetworking() error {
 	var err error
 	if container.hostConfig.NetworkMode.IsHost() {
@@ -1280,6 +1393,10 @@ func (container *Container) verifyDaemonSettings() {
 		log.Warnf("Your kernel does not support swap limit capabilities. Limitation discarded.")
 		container.Config.MemorySwap = -1
 	}
+	if container.hostConfig.PidsLimit > 0 && !container.daemon.sysInfo.PidsLimit {
+		log.Warnf("Your kernel does not support pids limit capabilities. Limitation discarded.")
+		container.hostConfig.PidsLimit = 0
+	}
 	if container.daemon.sysInfo.IPv4ForwardingDisabled {
 		log.Warnf("IPv4 forwarding is disabled. Networking will not work")
 	}
@@ -1402,37 +1519,43 @@ func (container *Container) setupWorkingDirectory() error {
 }
 
 func (container *Container) startLogging() error {
-	cfg := container.hostConfig.LogConfig
-	if cfg.Type == "" {
-		cfg = container.daemon.defaultLogConfig
+	cfg := container.daemon.mergeLogConfig(container.hostConfig.LogConfig)
+	ctx := logger.Context{
+		Config:             cfg.Config,
+		ContainerID:        container.ID,
+		ContainerName:      container.Name,
+		ContainerImageID:   container.ImageID,
+		ContainerImageName: container.Config.Image,
+		ContainerCreated:   container.Created,
+		ContainerLabels:    container.Config.Labels,
+		ContainerEnv:       container.Config.Env,
+	}
+	if cfg.Type == "none" {
+		return nil
 	}
-	var l logger.Logger
-	switch cfg.Type {
-	case "json-file":
+	create, err := logger.GetLogDriver(cfg.Type)
+	if err != nil {
+		return err
+	}
+	if cfg.Type == jsonfilelog.Name {
 		pth, err := container.logPath("json")
 		if err != nil {
 			return err
 		}
 		container.LogPath = pth
-
-		dl, err := jsonfilelog.New(pth)
-		if err != nil {
-			return err
-		}
-		l = dl
-	case "syslog":
-		dl, err := syslog.New(container.ID[:12])
-		if err != nil {
-			return err
-		}
-		l = dl
-	case "none":
-		return nil
-	default:
-		return fmt.Errorf("Unknown logging driver: %s", cfg.Type)
+		ctx.LogPath = pth
+	}
+	l, err := create(ctx)
+	if err != nil {
+		return err
 	}
 
-	copier, err := logger.NewCopier(container.ID, map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
+	multiline, err := logger.ParseMultilineConfig(cfg.Config)
+	if err != nil {
+		l.Close()
+		return err
+	}
+	copier, err := logger.NewCopier(container.ID, map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l, multiline)
 	if err != nil {
 		return err
 	}
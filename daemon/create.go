@@ -2,11 +2,15 @@ package daemon
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
+	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/libcontainer/label"
@@ -26,8 +30,33 @@ func (daemon *Daemon) ContainerCreate(job *engine.Job) engine.Status {
 	if len(hostConfig.LxcConf) > 0 && !strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
 		return job.Errorf("Cannot use --lxc-conf with execdriver: %s", daemon.ExecutionDriver().Name())
 	}
-	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
-		return job.Errorf("Minimum memory limit allowed is 4MB")
+	if len(hostConfig.Sysctls) > 0 && strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
+		return job.Errorf("Cannot use --sysctl with execdriver: %s", daemon.ExecutionDriver().Name())
+	}
+	if hostConfig.Init && strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
+		return job.Errorf("Cannot use --init with execdriver: %s", daemon.ExecutionDriver().Name())
+	}
+	for _, opt := range hostConfig.SecurityOpt {
+		if strings.HasPrefix(opt, "seccomp") && strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
+			return job.Errorf("Cannot use --security-opt seccomp with execdriver: %s", daemon.ExecutionDriver().Name())
+		}
+		if strings.HasPrefix(opt, "no-new-privileges") && strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
+			return job.Errorf("Cannot use --security-opt no-new-privileges with execdriver: %s", daemon.ExecutionDriver().Name())
+		}
+	}
+	if err := daemon.verifyLogConfig(hostConfig.LogConfig); err != nil {
+		return job.Error(err)
+	}
+	if err := verifySysctls(hostConfig); err != nil {
+		return job.Error(err)
+	}
+	if err := verifyTmpfs(config, hostConfig); err != nil {
+		return job.Error(err)
+	}
+	if daemon.config.RemappedRoot != "" {
+		if err := verifyRemappedRoot(hostConfig); err != nil {
+			return job.Error(err)
+		}
 	}
 	if hostConfig.Memory > 0 && !daemon.SystemConfig().MemoryLimit {
 		job.Errorf("Your kernel does not support memory limit capabilities. Limitation discarded.\n")
@@ -38,11 +67,12 @@ func (daemon *Daemon) ContainerCreate(job *engine.Job) engine.Status {
 		hostConfig.MemorySwap = -1
 	}
 	hostConfig.MemorySwap = -1
-	if hostConfig.Memory > 0 && hostConfig.MemorySwap > 0 && hostConfig.MemorySwap < hostConfig.Memory {
-		return job.Errorf("Minimum memoryswap limit should be larger than memory limit, see usage.\n")
+	if hostConfig.PidsLimit > 0 && !daemon.SystemConfig().PidsLimit {
+		job.Errorf("Your kernel does not support pids limit capabilities. Limitation discarded.\n")
+		hostConfig.PidsLimit = 0
 	}
-	if hostConfig.Memory == 0 && hostConfig.MemorySwap > 0 {
-		return job.Errorf("You should always set the Memory limit when using Memoryswap limit, see usage.\n")
+	if err := daemon.verifyResources(hostConfig); err != nil {
+		return job.Error(err)
 	}
 
 	container, buildWarnings, err := daemon.Create(config, hostConfig, name)
@@ -59,6 +89,10 @@ func (daemon *Daemon) ContainerCreate(job *engine.Job) engine.Status {
 	if !container.Config.NetworkDisabled && daemon.SystemConfig().IPv4ForwardingDisabled {
 		job.Errorf("IPv4 forwarding is disabled.\n")
 	}
+	if (container.SeccompProfile == "" || container.SeccompProfile == "default") && !hostConfig.Privileged &&
+		strings.HasPrefix(daemon.ExecutionDriver().Name(), "native") && !daemon.SystemConfig().Seccomp {
+		job.Errorf("Your kernel does not support seccomp, the syscalls of the container are not filtered.\n")
+	}
 	container.LogEvent("create")
 
 	job.Printf("%s\n", container.ID)
@@ -109,7 +143,7 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 	if err := daemon.Register(container); err != nil {
 		return nil, nil, err
 	}
-	if err := daemon.createRootfs(container); err != nil {
+	if err := daemon.createRootfs(container, hostConfig); err != nil {
 		return nil, nil, err
 	}
 	if hostConfig != nil {
@@ -148,3 +182,159 @@ func (daemon *Daemon) GenerateSecurityOpt(ipcMode runconfig.IpcMode, pidMode run
 	}
 	return nil, nil
 }
+
+// verifyLogConfig checks the logging driver of a container and its options,
+// merged over the daemon defaults
+func (daemon *Daemon) verifyLogConfig(logConfig runconfig.LogConfig) error {
+	cfg := daemon.mergeLogConfig(logConfig)
+	if cfg.Type != "none" {
+		if _, err := logger.GetLogDriver(cfg.Type); err != nil {
+			return err
+		}
+	}
+	_, err := logger.ParseMultilineConfig(cfg.Config)
+	return err
+}
+
+// verifyResources checks the resources of hostConfig are valid, and supported
+// by the kernel
+func (daemon *Daemon) verifyResources(hostConfig *runconfig.HostConfig) error {
+	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
+		return fmt.Errorf("Minimum memory limit allowed is 4MB")
+	}
+	if hostConfig.Memory > 0 && !daemon.SystemConfig().MemoryLimit {
+		return fmt.Errorf("Your kernel does not support memory limit capabilities")
+	}
+	if hostConfig.CpusetCpus != "" {
+		cpus, err := parsers.ParseUintList(hostConfig.CpusetCpus)
+		if err != nil {
+			return fmt.Errorf("Invalid value %s for cpuset cpus", hostConfig.CpusetCpus)
+		}
+		if daemon.SystemConfig().Cpus != "" {
+			available, err := parsers.ParseUintList(daemon.SystemConfig().Cpus)
+			if err != nil {
+				return err
+			}
+			for cpu := range cpus {
+				if !available[cpu] {
+					return fmt.Errorf("Requested CPUs are not available - requested %s, available: %s", hostConfig.CpusetCpus, daemon.SystemConfig().Cpus)
+				}
+			}
+		}
+	}
+	if hostConfig.CpuShares < 0 {
+		return fmt.Errorf("CPU shares can't be negative")
+	}
+	if hostConfig.CpuPeriod < 0 || hostConfig.CpuQuota < 0 {
+		return fmt.Errorf("CPU period and quota can't be negative")
+	}
+	if hostConfig.CpuPeriod != 0 && (hostConfig.CpuPeriod < 1000 || hostConfig.CpuPeriod > 1000000) {
+		return fmt.Errorf("CPU period must be between 1ms (1000) and 1s (1000000)")
+	}
+	if hostConfig.CpuQuota != 0 && hostConfig.CpuQuota < 1000 {
+		return fmt.Errorf("Minimum CPU quota allowed is 1ms (1000)")
+	}
+	if hostConfig.BlkioWeight != 0 && (hostConfig.BlkioWeight < 10 || hostConfig.BlkioWeight > 1000) {
+		return fmt.Errorf("Block IO weight must be between 10 and 1000")
+	}
+	if hostConfig.PidsLimit < 0 {
+		return fmt.Errorf("Pids limit can't be negative")
+	}
+	if hostConfig.MemoryReservation < 0 || hostConfig.KernelMemory < 0 {
+		return fmt.Errorf("Memory reservation and kernel memory limit can't be negative")
+	}
+	if (hostConfig.MemoryReservation > 0 || hostConfig.KernelMemory > 0 || hostConfig.OomKillDisable) && !daemon.SystemConfig().MemoryLimit {
+		return fmt.Errorf("Your kernel does not support memory limit capabilities")
+	}
+	if hostConfig.Memory > 0 && hostConfig.MemoryReservation > hostConfig.Memory {
+		return fmt.Errorf("Memory reservation can't be larger than the memory limit")
+	}
+	if hostConfig.KernelMemory != 0 && hostConfig.KernelMemory < 4194304 {
+		return fmt.Errorf("Minimum kernel memory limit allowed is 4MB")
+	}
+	return nil
+}
+
+// verifySysctls checks the sysctls of hostConfig are namespaced, and that the
+// container has its own namespace for them
+func verifySysctls(hostConfig *runconfig.HostConfig) error {
+	for key := range hostConfig.Sysctls {
+		if !opts.IsNamespacedSysctl(key) {
+			return fmt.Errorf("sysctl %q is not namespaced and can't be set in a container", key)
+		}
+		if strings.HasPrefix(key, "net.") {
+			if !hostConfig.NetworkMode.IsPrivate() && !hostConfig.NetworkMode.IsNone() {
+				return fmt.Errorf("sysctl %q can't be set with --net=%s, the network namespace isn't the container's own", key, hostConfig.NetworkMode)
+			}
+		} else if !hostConfig.IpcMode.IsPrivate() {
+			return fmt.Errorf("sysctl %q can't be set with --ipc=%s, the IPC namespace isn't the container's own", key, hostConfig.IpcMode)
+		}
+	}
+	return nil
+}
+
+// verifyRemappedRoot checks hostConfig doesn't give the container the
+// privileges or namespaces of the host, which a container whose root is
+// remapped in a user namespace can't have
+func verifyRemappedRoot(hostConfig *runconfig.HostConfig) error {
+	if hostConfig.Privileged {
+		return fmt.Errorf("Privileged containers can't be run when the daemon remaps the root of user namespaces")
+	}
+	if hostConfig.NetworkMode.IsHost() {
+		return fmt.Errorf("--net=host can't be used when the daemon remaps the root of user namespaces")
+	}
+	if hostConfig.PidMode.IsHost() {
+		return fmt.Errorf("--pid=host can't be used when the daemon remaps the root of user namespaces")
+	}
+	if hostConfig.IpcMode.IsHost() {
+		return fmt.Errorf("--ipc=host can't be used when the daemon remaps the root of user namespaces")
+	}
+	return nil
+}
+
+// verifyHealthcheck checks the test of the health check hc, which may be nil,
+// is empty, NONE, or a command to run with CMD or CMD-SHELL
+func verifyHealthcheck(hc *runconfig.HealthConfig) error {
+	if hc == nil || len(hc.Test) == 0 {
+		return nil
+	}
+	switch hc.Test[0] {
+	case "NONE":
+		if len(hc.Test) != 1 {
+			return fmt.Errorf("Health check NONE takes no arguments")
+		}
+	case "CMD", "CMD-SHELL":
+		if len(hc.Test) < 2 {
+			return fmt.Errorf("Health check %s requires a command", hc.Test[0])
+		}
+	default:
+		return fmt.Errorf("Invalid health check test %q, it must start with NONE, CMD or CMD-SHELL", hc.Test[0])
+	}
+	return nil
+}
+
+// verifyTmpfs checks the tmpfs of hostConfig are mounted on valid paths with
+// valid options, and that they don't conflict with the volumes of the container
+func verifyTmpfs(config *runconfig.Config, hostConfig *runconfig.HostConfig) error {
+	volumes := make(map[string]struct{})
+	for v := range config.Volumes {
+		volumes[path.Clean(v)] = struct{}{}
+	}
+	for _, bind := range hostConfig.Binds {
+		if arr := strings.Split(bind, ":"); len(arr) > 1 {
+			volumes[path.Clean(arr[1])] = struct{}{}
+		}
+	}
+	for dest, options := range hostConfig.Tmpfs {
+		if !path.IsAbs(dest) || path.Clean(dest) == "/" {
+			return fmt.Errorf("Invalid tmpfs path %q, it must be absolute and can't be /", dest)
+		}
+		if _, _, err := mount.ParseTmpfsOptions(options); err != nil {
+			return fmt.Errorf("Invalid options for the tmpfs %s: %v", dest, err)
+		}
+		if _, exists := volumes[path.Clean(dest)]; exists {
+			return fmt.Errorf("Conflicting options: the tmpfs %s is also a volume of the container", dest)
+		}
+	}
+	return nil
+}
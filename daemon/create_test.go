@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestVerifySysctls(t *testing.T) {
+	valid := []*runconfig.HostConfig{
+		{Sysctls: map[string]string{"net.core.somaxconn": "1024", "kernel.shmmax": "68719476736"}},
+		{Sysctls: map[string]string{"net.core.somaxconn": "1024"}, NetworkMode: "none", IpcMode: "host"},
+		{Sysctls: map[string]string{"kernel.msgmax": "65536"}, NetworkMode: "host"},
+	}
+	for _, hostConfig := range valid {
+		if err := verifySysctls(hostConfig); err != nil {
+			t.Fatalf("Expected the sysctls of %+v to be valid: %v", hostConfig, err)
+		}
+	}
+
+	invalid := []*runconfig.HostConfig{
+		{Sysctls: map[string]string{"vm.swappiness": "10"}},
+		{Sysctls: map[string]string{"net.core.somaxconn": "1024"}, NetworkMode: "host"},
+		{Sysctls: map[string]string{"net.core.somaxconn": "1024"}, NetworkMode: "container:db"},
+		{Sysctls: map[string]string{"fs.mqueue.msg_max": "100"}, IpcMode: "container:db"},
+	}
+	for _, hostConfig := range invalid {
+		if err := verifySysctls(hostConfig); err == nil {
+			t.Fatalf("Expected the sysctls of %+v to be invalid", hostConfig)
+		}
+	}
+}
+
+func TestVerifyTmpfs(t *testing.T) {
+	config := &runconfig.Config{Volumes: map[string]struct{}{"/data": {}}}
+	hostConfig := &runconfig.HostConfig{
+		Binds: []string{"/srv/logs:/var/log:ro"},
+		Tmpfs: map[string]string{"/run": "", "/tmp": "size=64m,mode=1777"},
+	}
+	if err := verifyTmpfs(config, hostConfig); err != nil {
+		t.Fatalf("Expected the tmpfs to be valid: %v", err)
+	}
+
+	for _, tmpfs := range []map[string]string{
+		{"run": ""},
+		{"/": ""},
+		{"/tmp": "size=64m,foo=bar"},
+		{"/data/": ""},
+		{"/var/log": "size=1m"},
+	} {
+		hostConfig.Tmpfs = tmpfs
+		if err := verifyTmpfs(config, hostConfig); err == nil {
+			t.Fatalf("Expected the tmpfs %v to be invalid", tmpfs)
+		}
+	}
+}
+
+func TestVerifyRemappedRoot(t *testing.T) {
+	if err := verifyRemappedRoot(&runconfig.HostConfig{NetworkMode: "bridge", IpcMode: "container:db"}); err != nil {
+		t.Fatalf("Expected the host config to be valid: %v", err)
+	}
+
+	for _, hostConfig := range []*runconfig.HostConfig{
+		{Privileged: true},
+		{NetworkMode: "host"},
+		{PidMode: "host"},
+		{IpcMode: "host"},
+	} {
+		if err := verifyRemappedRoot(hostConfig); err == nil {
+			t.Fatalf("Expected %+v to be invalid with a remapped root", hostConfig)
+		}
+	}
+}
+
+func TestVerifyHealthcheck(t *testing.T) {
+	valid := []*runconfig.HealthConfig{
+		nil,
+		{},
+		{Test: []string{"NONE"}},
+		{Test: []string{"CMD", "curl", "-f", "http://localhost/"}},
+		{Test: []string{"CMD-SHELL", "curl -f http://localhost/ || exit 1"}},
+	}
+	for _, hc := range valid {
+		if err := verifyHealthcheck(hc); err != nil {
+			t.Fatalf("Expected the health check %+v to be valid: %v", hc, err)
+		}
+	}
+
+	invalid := []*runconfig.HealthConfig{
+		{Test: []string{"NONE", "true"}},
+		{Test: []string{"CMD"}},
+		{Test: []string{"CMD-SHELL"}},
+		{Test: []string{"curl", "-f", "http://localhost/"}},
+		{Test: []string{"cmd", "true"}},
+	}
+	for _, hc := range invalid {
+		if err := verifyHealthcheck(hc); err == nil {
+			t.Fatalf("Expected the health check %+v to be invalid", hc)
+		}
+	}
+}
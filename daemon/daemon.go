@@ -33,11 +33,13 @@ import (
 	"github.com/docker/docker/pkg/broadcastwriter"
 	"github.com/docker/docker/pkg/common"
 	"github.com/docker/docker/pkg/graphdb"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/docker/docker/pkg/networkfs/resolvconf"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/kernel"
+	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/docker/docker/pkg/truncindex"
 	"github.com/docker/docker/runconfig"
@@ -107,40 +109,46 @@ type Daemon struct {
 	trustStore       *trust.TrustStore
 	statsCollector   *statsCollector
 	defaultLogConfig runconfig.LogConfig
+	uidMaps          []idtools.IDMap
+	gidMaps          []idtools.IDMap
 }
 
 // Install installs daemon capabilities to eng.
 func (daemon *Daemon) Install(eng *engine.Engine) error {
 	// FIXME: remove ImageDelete's dependency on Daemon, then move to graph/
 	for name, method := range map[string]engine.Handler{
-		"attach":            daemon.ContainerAttach,
-		"commit":            daemon.ContainerCommit,
-		"container_changes": daemon.ContainerChanges,
-		"container_copy":    daemon.ContainerCopy,
-		"container_rename":  daemon.ContainerRename,
-		"container_inspect": daemon.ContainerInspect,
-		"container_stats":   daemon.ContainerStats,
-		"containers":        daemon.Containers,
-		"create":            daemon.ContainerCreate,
-		"rm":                daemon.ContainerRm,
-		"export":            daemon.ContainerExport,
-		"info":              daemon.CmdInfo,
-		"kill":              daemon.ContainerKill,
-		"logs":              daemon.ContainerLogs,
-		"pause":             daemon.ContainerPause,
-		"resize":            daemon.ContainerResize,
-		"restart":           daemon.ContainerRestart,
-		"start":             daemon.ContainerStart,
-		"stop":              daemon.ContainerStop,
-		"top":               daemon.ContainerTop,
-		"unpause":           daemon.ContainerUnpause,
-		"wait":              daemon.ContainerWait,
-		"image_delete":      daemon.ImageDelete, // FIXME: see above
-		"image_clean":       daemon.ImageClean,
-		"execCreate":        daemon.ContainerExecCreate,
-		"execStart":         daemon.ContainerExecStart,
-		"execResize":        daemon.ContainerExecResize,
-		"execInspect":       daemon.ContainerExecInspect,
+		"attach":                  daemon.ContainerAttach,
+		"commit":                  daemon.ContainerCommit,
+		"container_changes":       daemon.ContainerChanges,
+		"container_copy":          daemon.ContainerCopy,
+		"container_rename":        daemon.ContainerRename,
+		"container_inspect":       daemon.ContainerInspect,
+		"container_stats":         daemon.ContainerStats,
+		"container_stats_history": daemon.ContainerStatsHistory,
+		"containers":              daemon.Containers,
+		"create":                  daemon.ContainerCreate,
+		"rm":                      daemon.ContainerRm,
+		"export":                  daemon.ContainerExport,
+		"info":                    daemon.CmdInfo,
+		"kill":                    daemon.ContainerKill,
+		"logs":                    daemon.ContainerLogs,
+		"containers_logs":         daemon.ContainersLogs,
+		"pause":                   daemon.ContainerPause,
+		"resize":                  daemon.ContainerResize,
+		"restart":                 daemon.ContainerRestart,
+		"start":                   daemon.ContainerStart,
+		"stop":                    daemon.ContainerStop,
+		"top":                     daemon.ContainerTop,
+		"unpause":                 daemon.ContainerUnpause,
+		"update":                  daemon.ContainerUpdate,
+		"wait":                    daemon.ContainerWait,
+		"image_delete":            daemon.ImageDelete, // FIXME: see above
+		"image_clean":             daemon.ImageClean,
+		"metrics":                 daemon.Metrics,
+		"execCreate":              daemon.ContainerExecCreate,
+		"execStart":               daemon.ContainerExecStart,
+		"execResize":              daemon.ContainerExecResize,
+		"execInspect":             daemon.ContainerExecInspect,
 	} {
 		if err := eng.Register(name, method); err != nil {
 			return err
@@ -255,6 +263,9 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 	}
 	// done
 	daemon.containers.Add(container.ID, container)
+	if daemon.statsCollector != nil {
+		daemon.statsCollector.track(container)
+	}
 
 	// don't update the Suffixarray if we're starting up
 	// we'll waste time if we update it for every container
@@ -398,13 +409,16 @@ func (daemon *Daemon) restore() error {
 	}
 
 	// check the restart policy on the containers and restart any container with
-	// the restart policy of "always"
+	// the restart policy of "always", or "unless-stopped" if the user didn't
+	// stop it
 	if daemon.config.AutoRestart {
 		log.Debugf("Restarting containers...")
 
 		for _, container := range registeredContainers {
-			if container.hostConfig.RestartPolicy.Name == "always" ||
-				(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0) {
+			policy := container.hostConfig.RestartPolicy.Name
+			if policy == "always" ||
+				(policy == "unless-stopped" && !container.HasBeenManuallyStopped) ||
+				((policy == "on-failure" || policy == "on-unhealthy") && container.ExitCode != 0) {
 				log.Debugf("Starting container %s", container.ID)
 
 				if err := container.Start(); err != nil {
@@ -506,6 +520,9 @@ func (daemon *Daemon) mergeAndVerifyConfig(config *runconfig.Config, img *image.
 	if len(config.Entrypoint) == 0 && len(config.Cmd) == 0 {
 		return nil, fmt.Errorf("No command specified")
 	}
+	if err := verifyHealthcheck(config.Healthcheck); err != nil {
+		return nil, err
+	}
 	return warnings, nil
 }
 
@@ -616,16 +633,34 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 		err       error
 	)
 
+	container.SeccompProfile = ""
+	container.NoNewPrivileges = false
 	for _, opt := range config.SecurityOpt {
-		con := strings.SplitN(opt, ":", 2)
-		if len(con) == 1 {
+		if opt == "no-new-privileges" {
+			container.NoNewPrivileges = true
+			continue
+		}
+		// the other options are KEY:VALUE, or KEY=VALUE
+		i := strings.IndexAny(opt, ":=")
+		if i == -1 {
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
-		switch con[0] {
+		switch key, value := opt[:i], opt[i+1:]; key {
 		case "label":
-			labelOpts = append(labelOpts, con[1])
+			labelOpts = append(labelOpts, value)
 		case "apparmor":
-			container.AppArmorProfile = con[1]
+			container.AppArmorProfile = value
+		case "seccomp":
+			if value != "default" && value != "unconfined" {
+				if err := execdriver.ValidateSeccompProfile(value); err != nil {
+					return err
+				}
+			}
+			container.SeccompProfile = value
+		case "no-new-privileges":
+			if container.NoNewPrivileges, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("Invalid --security-opt: %q", opt)
+			}
 		default:
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
@@ -635,6 +670,26 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	return err
 }
 
+// resolveSeccompProfile sets the seccomp profile of the container to the one
+// the exec driver will apply: "default", "unconfined" or a custom profile
+func (daemon *Daemon) resolveSeccompProfile(container *Container, hostConfig *runconfig.HostConfig) error {
+	switch {
+	case !strings.HasPrefix(daemon.ExecutionDriver().Name(), "native"):
+		// only the native driver filters syscalls
+		container.SeccompProfile = ""
+	case hostConfig.Privileged:
+		container.SeccompProfile = "unconfined"
+	case container.SeccompProfile == "" || container.SeccompProfile == "default":
+		container.SeccompProfile = "default"
+		if !daemon.SystemConfig().Seccomp {
+			container.SeccompProfile = "unconfined"
+		}
+	case container.SeccompProfile != "unconfined" && !daemon.SystemConfig().Seccomp:
+		return fmt.Errorf("Your kernel does not support seccomp, the custom seccomp profile can't be applied")
+	}
+	return nil
+}
+
 func (daemon *Daemon) newContainer(name string, config *runconfig.Config, imgID string) (*Container, error) {
 	var (
 		id  string
@@ -669,12 +724,16 @@ func (daemon *Daemon) newContainer(name string, config *runconfig.Config, imgID
 	return container, err
 }
 
-func (daemon *Daemon) createRootfs(container *Container) error {
+func (daemon *Daemon) createRootfs(container *Container, hostConfig *runconfig.HostConfig) error {
 	// Step 1: create the container directory.
 	// This doubles as a barrier to avoid race conditions.
 	if err := os.Mkdir(container.root, 0700); err != nil {
 		return err
 	}
+	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	if err := os.Chown(container.root, rootUID, rootGID); err != nil {
+		return err
+	}
 	initID := fmt.Sprintf("%s-init", container.ID)
 	if err := daemon.driver.Create(initID, container.ImageID); err != nil {
 		return err
@@ -685,10 +744,22 @@ func (daemon *Daemon) createRootfs(container *Container) error {
 	}
 	defer daemon.driver.Put(initID)
 
-	if err := graph.SetupInitLayer(initPath); err != nil {
+	if err := graph.SetupInitLayer(initPath, rootUID, rootGID); err != nil {
 		return err
 	}
 
+	// The mountpoints of the tmpfs are created in the init layer, so that
+	// they are not part of the changes of the container
+	for dest := range hostConfig.Tmpfs {
+		mountpoint, err := symlink.FollowSymlinkInScope(filepath.Join(initPath, dest), initPath)
+		if err != nil {
+			return err
+		}
+		if err := idtools.MkdirAllAs(mountpoint, 0755, rootUID, rootGID); err != nil {
+			return err
+		}
+	}
+
 	if err := daemon.driver.Create(container.ID, initID); err != nil {
 		return err
 	}
@@ -873,11 +944,46 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		return nil, err
 	}
 
+	uidMaps, gidMaps, err := setupRemappedRoot(config)
+	if err != nil {
+		return nil, err
+	}
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	if uidMaps != nil {
+		// The images and containers of each remapped root live in their
+		// own directory, which the root of the containers must be able to
+		// reach
+		if err := os.Chmod(config.Root, 0701); err != nil {
+			return nil, err
+		}
+		config.Root = filepath.Join(config.Root, fmt.Sprintf("%d.%d", rootUID, rootGID))
+		if err := idtools.MkdirAllAs(config.Root, 0700, rootUID, rootGID); err != nil {
+			return nil, err
+		}
+		log.Infof("User namespaces: containers' root is uid %d and gid %d of the host", rootUID, rootGID)
+	}
+
+	if config.EventsJournalMaxSize > 0 {
+		job := eng.Job("events_journal", filepath.Join(config.Root, "events"))
+		job.SetenvInt64("MaxSize", config.EventsJournalMaxSize)
+		if err := job.Run(); err != nil {
+			return nil, fmt.Errorf("Unable to open events journal: %s", err)
+		}
+	}
+	for _, webhook := range config.EventsWebhooks {
+		if err := eng.Job("events_webhook", webhook).Run(); err != nil {
+			return nil, fmt.Errorf("Unable to add events webhook: %s", err)
+		}
+	}
+
 	// Set the default driver
 	graphdriver.DefaultDriver = config.GraphDriver
 
 	// Load storage driver
-	driver, err := graphdriver.New(config.Root, config.GraphOptions)
+	driver, err := graphdriver.New(config.Root, config.GraphOptions, uidMaps, gidMaps)
 	if err != nil {
 		return nil, fmt.Errorf("error intializing graphdriver: %v", err)
 	}
@@ -905,7 +1011,7 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 
 	daemonRepo := path.Join(config.Root, "containers")
 
-	if err := os.MkdirAll(daemonRepo, 0700); err != nil && !os.IsExist(err) {
+	if err := idtools.MkdirAllAs(daemonRepo, 0700, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
@@ -920,7 +1026,7 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		return nil, err
 	}
 
-	volumesDriver, err := graphdriver.GetDriver("vfs", config.Root, config.GraphOptions)
+	volumesDriver, err := graphdriver.GetDriver("vfs", config.Root, config.GraphOptions, uidMaps, gidMaps)
 	if err != nil {
 		return nil, err
 	}
@@ -995,18 +1101,8 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		return nil, fmt.Errorf("Could not locate dockerinit: This usually means docker was built incorrectly. See http://docs.docker.com/contributing/devenvironment for official build instructions.")
 	}
 
-	if sysInitPath != localCopy {
-		// When we find a suitable dockerinit binary (even if it's our local binary), we copy it into config.Root at localCopy for future use (so that the original can go away without that being a problem, for example during a package upgrade).
-		if err := os.Mkdir(path.Dir(localCopy), 0700); err != nil && !os.IsExist(err) {
-			return nil, err
-		}
-		if _, err := utils.CopyFile(sysInitPath, localCopy); err != nil {
-			return nil, err
-		}
-		if err := os.Chmod(localCopy, 0700); err != nil {
-			return nil, err
-		}
-		sysInitPath = localCopy
+	if sysInitPath, err = setupInitCopy(sysInitPath, localCopy); err != nil {
+		return nil, err
 	}
 
 	sysInfo := sysinfo.New(false)
@@ -1033,8 +1129,10 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		execDriver:       ed,
 		eng:              eng,
 		trustStore:       t,
-		statsCollector:   newStatsCollector(1 * time.Second),
+		statsCollector:   newStatsCollector(1*time.Second, config.StatsHistory),
 		defaultLogConfig: config.LogConfig,
+		uidMaps:          uidMaps,
+		gidMaps:          gidMaps,
 	}
 
 	eng.OnShutdown(func() {
@@ -1043,6 +1141,14 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		}
 	})
 
+	if config.MetricsPush != "" {
+		exporter, err := newMetricsExporter(daemon, config.MetricsPush, config.MetricsPushPrefix, config.MetricsPushInterval)
+		if err != nil {
+			return nil, err
+		}
+		daemon.statsCollector.setExporter(exporter)
+	}
+
 	if err := daemon.restore(); err != nil {
 		return nil, err
 	}
@@ -1209,10 +1315,68 @@ func (daemon *Daemon) SystemConfig() *sysinfo.SysInfo {
 	return daemon.sysInfo
 }
 
+// GetRemappedUIDGID returns the host uid and gid of the root of the
+// containers, which is 0 unless the daemon runs with --userns-remap
+func (daemon *Daemon) GetRemappedUIDGID() (int, int) {
+	uid, gid, _ := idtools.GetRootUIDGID(daemon.uidMaps, daemon.gidMaps)
+	return uid, gid
+}
+
+// GetUIDGIDMaps returns the uid and gid mappings of the user namespace of
+// the containers, which are nil unless the daemon runs with --userns-remap
+func (daemon *Daemon) GetUIDGIDMaps() ([]idtools.IDMap, []idtools.IDMap) {
+	return daemon.uidMaps, daemon.gidMaps
+}
+
+// mergeLogConfig returns the logging configuration of a container. Its
+// options are merged over the daemon's when it uses the daemon's driver.
+func (daemon *Daemon) mergeLogConfig(cfg runconfig.LogConfig) runconfig.LogConfig {
+	if cfg.Type != "" && cfg.Type != daemon.defaultLogConfig.Type {
+		return cfg
+	}
+	merged := runconfig.LogConfig{
+		Type:   daemon.defaultLogConfig.Type,
+		Config: make(map[string]string),
+	}
+	for k, v := range daemon.defaultLogConfig.Config {
+		merged.Config[k] = v
+	}
+	for k, v := range cfg.Config {
+		merged.Config[k] = v
+	}
+	return merged
+}
+
 func (daemon *Daemon) SystemInitPath() string {
 	return daemon.sysInitPath
 }
 
+// setupInitCopy copies the dockerinit binary found at sysInitPath to
+// localCopy, unless it is localCopy already, and returns the path of the copy.
+// The copy is owned by root and can be run by any user, since the containers
+// started with --init run it as their user, and its directory can be
+// traversed by the remapped root of user namespaces, which mounts it.
+func setupInitCopy(sysInitPath, localCopy string) (string, error) {
+	dir := path.Dir(localCopy)
+	if err := os.Mkdir(dir, 0711); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	// the directory was created with 0700 by older versions
+	if err := os.Chmod(dir, 0711); err != nil {
+		return "", err
+	}
+	if sysInitPath != localCopy {
+		// When we find a suitable dockerinit binary (even if it's our local binary), we copy it into config.Root at localCopy for future use (so that the original can go away without that being a problem, for example during a package upgrade).
+		if _, err := utils.CopyFile(sysInitPath, localCopy); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Chmod(localCopy, 0755); err != nil {
+		return "", err
+	}
+	return localCopy, nil
+}
+
 func (daemon *Daemon) GraphDriver() graphdriver.Driver {
 	return daemon.driver
 }
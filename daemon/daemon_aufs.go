@@ -14,7 +14,8 @@ import (
 func migrateIfAufs(driver graphdriver.Driver, root string) error {
 	if ad, ok := driver.(*aufs.Driver); ok {
 		log.Debugf("Migrating existing containers")
-		if err := ad.Migrate(root, graph.SetupInitLayer); err != nil {
+		setupInit := func(p string) error { return graph.SetupInitLayer(p, 0, 0) }
+		if err := ad.Migrate(root, setupInit); err != nil {
 			return err
 		}
 	}
@@ -1,6 +1,12 @@
 package daemon
 
 import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/docker/docker/runconfig"
@@ -19,6 +25,54 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatalf("Unexpected AppArmorProfile, expected: \"test_profile\", got %q", container.AppArmorProfile)
 	}
 
+	// test seccomp
+	config.SecurityOpt = []string{`seccomp={"defaultAction":"SCMP_ACT_ALLOW"}`}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != `{"defaultAction":"SCMP_ACT_ALLOW"}` {
+		t.Fatalf("Unexpected SeccompProfile %q", container.SeccompProfile)
+	}
+	config.SecurityOpt = []string{"seccomp:unconfined"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != "unconfined" {
+		t.Fatalf("Unexpected SeccompProfile, expected: \"unconfined\", got %q", container.SeccompProfile)
+	}
+
+	config.SecurityOpt = []string{"seccomp=default"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != "default" {
+		t.Fatalf("Unexpected SeccompProfile, expected: \"default\", got %q", container.SeccompProfile)
+	}
+	config.SecurityOpt = []string{`seccomp={"defaultAction":"SCMP_ACT_LOG"}`}
+	if err := parseSecurityOpt(container, config); err == nil {
+		t.Fatal("Expected parseSecurityOpt error for an invalid seccomp profile, got nil")
+	}
+
+	// test no-new-privileges
+	config.SecurityOpt = []string{"no-new-privileges"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if !container.NoNewPrivileges {
+		t.Fatal("Expected NoNewPrivileges to be set")
+	}
+	config.SecurityOpt = []string{"no-new-privileges:false"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.NoNewPrivileges {
+		t.Fatal("Expected NoNewPrivileges not to be set")
+	}
+	config.SecurityOpt = []string{"no-new-privileges:maybe"}
+	if err := parseSecurityOpt(container, config); err == nil {
+		t.Fatal("Expected parseSecurityOpt error, got nil")
+	}
+
 	// test valid label
 	config.SecurityOpt = []string{"label:user:USER"}
 	if err := parseSecurityOpt(container, config); err != nil {
@@ -37,3 +91,92 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatal("Expected parseSecurityOpt error, got nil")
 	}
 }
+
+func TestMergeLogConfig(t *testing.T) {
+	daemon := &Daemon{defaultLogConfig: runconfig.LogConfig{
+		Type:   "json-file",
+		Config: map[string]string{"multiline-indent": "true", "multiline-max-size": "1m"},
+	}}
+
+	// the options of a container are merged over the daemon's
+	cfg := daemon.mergeLogConfig(runconfig.LogConfig{Config: map[string]string{"multiline-max-size": "2m"}})
+	if cfg.Type != "json-file" || cfg.Config["multiline-indent"] != "true" || cfg.Config["multiline-max-size"] != "2m" {
+		t.Fatalf("Unexpected merged config %+v", cfg)
+	}
+	if daemon.defaultLogConfig.Config["multiline-max-size"] != "1m" {
+		t.Fatal("Expected the daemon's config not to change")
+	}
+
+	// but not over the options of another driver
+	cfg = daemon.mergeLogConfig(runconfig.LogConfig{Type: "syslog"})
+	if cfg.Type != "syslog" || len(cfg.Config) != 0 {
+		t.Fatalf("Unexpected config %+v for another driver", cfg)
+	}
+
+	if err := daemon.verifyLogConfig(runconfig.LogConfig{Config: map[string]string{"multiline-pattern": "("}}); err == nil {
+		t.Fatal("Expected an invalid multiline-pattern to be rejected")
+	}
+	if err := daemon.verifyLogConfig(runconfig.LogConfig{Type: "unknown"}); err == nil {
+		t.Fatal("Expected an unknown driver to be rejected")
+	}
+	if err := daemon.verifyLogConfig(runconfig.LogConfig{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupInitCopy(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-init-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	// the users of the containers only need to traverse the root
+	if err := os.Chmod(tmp, 0711); err != nil {
+		t.Fatal(err)
+	}
+	sysInitPath := filepath.Join(tmp, "dockerinit")
+	if err := ioutil.WriteFile(sysInitPath, []byte("#!/bin/sh\nid -u\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	localCopy := filepath.Join(tmp, "init", "dockerinit-dev")
+
+	checkInitCopy := func() {
+		if fi, err := os.Stat(localCopy); err != nil || fi.Mode().Perm() != 0755 {
+			t.Fatalf("Expected the init copy to have mode 0755, got %v (%v)", fi.Mode(), err)
+		}
+		if fi, err := os.Stat(filepath.Dir(localCopy)); err != nil || fi.Mode().Perm() != 0711 {
+			t.Fatalf("Expected the init directory to have mode 0711, got %v (%v)", fi.Mode(), err)
+		}
+	}
+
+	if p, err := setupInitCopy(sysInitPath, localCopy); err != nil || p != localCopy {
+		t.Fatalf("Expected the init to be copied to %s, got %s (%v)", localCopy, p, err)
+	}
+	checkInitCopy()
+
+	// the copies made by older versions are only accessible by root
+	if err := os.Chmod(localCopy, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Dir(localCopy), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := setupInitCopy(localCopy, localCopy); err != nil || p != localCopy {
+		t.Fatalf("Expected the init copy %s to be used, got %s (%v)", localCopy, p, err)
+	}
+	checkInitCopy()
+
+	// the containers started with --init and a non-root user run it as their user
+	if os.Getuid() != 0 {
+		t.Skip("running the init copy as another user requires root")
+	}
+	cmd := exec.Command(localCopy)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: 1000, Gid: 1000}}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Expected the init copy to be run by uid 1000: %v (%s)", err, out)
+	}
+	if uid := strings.TrimSpace(string(out)); uid != "1000" {
+		t.Fatalf("Expected the init copy to run as uid 1000, got %s", uid)
+	}
+}
@@ -3,6 +3,7 @@ package execdriver
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/docker/docker/daemon/execdriver/native/template"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/cgroups/fs"
@@ -72,6 +74,7 @@ type Driver interface {
 	Terminate(c *Command) error                   // kill it with fire
 	Clean(id string) error                        // clean all traces of container exec
 	Stats(id string) (*ResourceStats, error)      // Get resource stats for a running container
+	Update(c *Command) error                      // Update the cgroups of a running container with its resources
 }
 
 // Network settings of the container
@@ -106,11 +109,22 @@ type NetworkInterface struct {
 }
 
 type Resources struct {
-	Memory     int64            `json:"memory"`
-	MemorySwap int64            `json:"memory_swap"`
-	CpuShares  int64            `json:"cpu_shares"`
-	CpusetCpus string           `json:"cpuset_cpus"`
-	Rlimits    []*ulimit.Rlimit `json:"rlimits"`
+	Memory               int64                     `json:"memory"`
+	MemorySwap           int64                     `json:"memory_swap"`
+	MemoryReservation    int64                     `json:"memory_reservation"`
+	KernelMemory         int64                     `json:"kernel_memory"`
+	OomKillDisable       bool                      `json:"oom_kill_disable"`
+	PidsLimit            int64                     `json:"pids_limit"`
+	CpuShares            int64                     `json:"cpu_shares"`
+	CpusetCpus           string                    `json:"cpuset_cpus"`
+	CpuPeriod            int64                     `json:"cpu_period"`
+	CpuQuota             int64                     `json:"cpu_quota"`
+	BlkioWeight          int64                     `json:"blkio_weight"`
+	BlkioDeviceReadBps   []*configs.ThrottleDevice `json:"blkio_device_read_bps"`
+	BlkioDeviceWriteBps  []*configs.ThrottleDevice `json:"blkio_device_write_bps"`
+	BlkioDeviceReadIOps  []*configs.ThrottleDevice `json:"blkio_device_read_iops"`
+	BlkioDeviceWriteIOps []*configs.ThrottleDevice `json:"blkio_device_write_iops"`
+	Rlimits              []*ulimit.Rlimit          `json:"rlimits"`
 }
 
 type ResourceStats struct {
@@ -118,6 +132,11 @@ type ResourceStats struct {
 	Read        time.Time `json:"read"`
 	MemoryLimit int64     `json:"memory_limit"`
 	SystemUsage uint64    `json:"system_usage"`
+	// OomKills is the number of processes of the container killed by the
+	// OOM killer, where the kernel reports it
+	OomKills uint64 `json:"oom_kills"`
+	// Pids is the number of processes in the container
+	Pids uint64 `json:"pids"`
 }
 
 type Mount struct {
@@ -147,6 +166,7 @@ type Command struct {
 	Rootfs             string            `json:"rootfs"` // root fs of the container
 	ReadonlyRootfs     bool              `json:"readonly_rootfs"`
 	InitPath           string            `json:"initpath"` // dockerinit
+	Init               bool              `json:"init"`     // run the process under dockerinit as a minimal init
 	WorkingDir         string            `json:"working_dir"`
 	ConfigPath         string            `json:"config_path"` // this should be able to be removed when the lxc template is moved into the driver
 	Network            *Network          `json:"network"`
@@ -164,7 +184,13 @@ type Command struct {
 	MountLabel         string            `json:"mount_label"`
 	LxcConfig          []string          `json:"lxc_config"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
+	SeccompProfile     string            `json:"seccomp_profile"` // "default", "unconfined" or the JSON of a custom profile
+	NoNewPrivileges    bool              `json:"no_new_privileges"`
 	CgroupParent       string            `json:"cgroup_parent"` // The parent cgroup for this command.
+	Sysctls            map[string]string `json:"sysctls"`
+	Tmpfs              map[string]string `json:"tmpfs"`      // mount options of the tmpfs, by their destination
+	UIDMapping         []idtools.IDMap   `json:"uidmapping"` // uid mappings of the user namespace, none to share the host's
+	GIDMapping         []idtools.IDMap   `json:"gidmapping"` // gid mappings of the user namespace
 }
 
 func InitContainer(c *Command) *configs.Config {
@@ -203,13 +229,76 @@ func SetupCgroups(container *configs.Config, c *Command) error {
 		container.Cgroups.CpuShares = c.Resources.CpuShares
 		container.Cgroups.Memory = c.Resources.Memory
 		container.Cgroups.MemoryReservation = c.Resources.Memory
+		if c.Resources.MemoryReservation != 0 {
+			container.Cgroups.MemoryReservation = c.Resources.MemoryReservation
+		}
 		container.Cgroups.MemorySwap = c.Resources.MemorySwap
+		container.Cgroups.KernelMemory = c.Resources.KernelMemory
+		container.Cgroups.OomKillDisable = c.Resources.OomKillDisable
+		container.Cgroups.PidsLimit = c.Resources.PidsLimit
 		container.Cgroups.CpusetCpus = c.Resources.CpusetCpus
+		container.Cgroups.CpuPeriod = c.Resources.CpuPeriod
+		container.Cgroups.CpuQuota = c.Resources.CpuQuota
+		container.Cgroups.BlkioWeight = c.Resources.BlkioWeight
+		container.Cgroups.BlkioThrottleReadBpsDevice = c.Resources.BlkioDeviceReadBps
+		container.Cgroups.BlkioThrottleWriteBpsDevice = c.Resources.BlkioDeviceWriteBps
+		container.Cgroups.BlkioThrottleReadIOPSDevice = c.Resources.BlkioDeviceReadIOps
+		container.Cgroups.BlkioThrottleWriteIOPSDevice = c.Resources.BlkioDeviceWriteIOps
 	}
 
 	return nil
 }
 
+// UpdateCgroups applies the resources of c to the cgroups of config, and
+// writes them with set. The cgroups of config are copied rather than changed
+// in place, and the memory+swap limit is raised first when it grows, since
+// the kernel rejects a memory limit above it. If writing the new limits
+// fails, the previous ones are written back and config is left unchanged.
+func UpdateCgroups(config *configs.Config, c *Command, set func(*configs.Config) error) error {
+	old := config.Cgroups
+	cgroup := *old
+	config.Cgroups = &cgroup
+	if err := SetupCgroups(config, c); err != nil {
+		return err
+	}
+	if err := setCgroups(config, memorySwapLimit(old), set); err != nil {
+		swap := memorySwapLimit(config.Cgroups)
+		config.Cgroups = old
+		if rerr := setCgroups(config, swap, set); rerr != nil {
+			return fmt.Errorf("%s, and restoring the previous limits failed: %s", err, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// setCgroups writes the cgroups of config with set, raising the memory+swap
+// limit first when it's above currentSwap
+func setCgroups(config *configs.Config, currentSwap int64, set func(*configs.Config) error) error {
+	if swap := memorySwapLimit(config.Cgroups); currentSwap > 0 && swap > currentSwap {
+		raised := *config
+		raisedCgroup := *config.Cgroups
+		raisedCgroup.Memory, raisedCgroup.MemoryReservation, raisedCgroup.MemorySwap = 0, 0, swap
+		raised.Cgroups = &raisedCgroup
+		if err := set(&raised); err != nil {
+			return err
+		}
+	}
+	return set(config)
+}
+
+// memorySwapLimit returns the memory+swap limit libcontainer writes for
+// cgroup, 0 if there is none
+func memorySwapLimit(cgroup *configs.Cgroup) int64 {
+	if cgroup.MemorySwap == 0 && cgroup.Memory != 0 {
+		return cgroup.Memory * 2
+	}
+	if cgroup.MemorySwap > 0 {
+		return cgroup.MemorySwap
+	}
+	return 0
+}
+
 // Returns the network statistics for the network interfaces represented by the NetworkRuntimeInfo.
 func getNetworkInterfaceStats(interfaceName string) (*libcontainer.NetworkInterface, error) {
 	out := &libcontainer.NetworkInterface{Name: interfaceName}
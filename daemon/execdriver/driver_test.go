@@ -0,0 +1,132 @@
+package execdriver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/libcontainer/configs"
+)
+
+func TestUpdateCgroups(t *testing.T) {
+	config := &configs.Config{Cgroups: &configs.Cgroup{Memory: 64 << 20, MemorySwap: 128 << 20}}
+	old := config.Cgroups
+	c := &Command{Resources: &Resources{Memory: 256 << 20, MemorySwap: 512 << 20, CpuShares: 512}}
+
+	var set []configs.Cgroup
+	err := UpdateCgroups(config, c, func(config *configs.Config) error {
+		set = append(set, *config.Cgroups)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 2 {
+		t.Fatalf("Expected the cgroups to be set twice, got %d", len(set))
+	}
+	// the memory+swap limit is raised before the memory limit
+	if set[0].Memory != 0 || set[0].MemorySwap != 512<<20 {
+		t.Fatalf("Unexpected first cgroups %+v", set[0])
+	}
+	if set[1].Memory != 256<<20 || set[1].MemorySwap != 512<<20 || set[1].CpuShares != 512 {
+		t.Fatalf("Unexpected cgroups %+v", set[1])
+	}
+	if old.Memory != 64<<20 {
+		t.Fatal("Expected the old cgroups to be left unchanged")
+	}
+
+	// lowering the limits sets the cgroups once
+	set = nil
+	c.Resources = &Resources{Memory: 32 << 20, MemorySwap: 64 << 20}
+	if err := UpdateCgroups(config, c, func(config *configs.Config) error {
+		set = append(set, *config.Cgroups)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 1 || set[0].Memory != 32<<20 {
+		t.Fatalf("Unexpected cgroups %+v", set)
+	}
+}
+
+func TestUpdateCgroupsRollback(t *testing.T) {
+	config := &configs.Config{Cgroups: &configs.Cgroup{Memory: 64 << 20, MemorySwap: 128 << 20, CpusetCpus: "0"}}
+	c := &Command{Resources: &Resources{Memory: 256 << 20, MemorySwap: 512 << 20, CpusetCpus: "7"}}
+
+	var set []configs.Cgroup
+	err := UpdateCgroups(config, c, func(config *configs.Config) error {
+		set = append(set, *config.Cgroups)
+		if config.Cgroups.CpusetCpus == "7" && config.Cgroups.Memory != 0 {
+			return errors.New("invalid argument")
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Expected the update to fail")
+	}
+	// the raised memory+swap limit and the partly written limits are
+	// restored
+	if len(set) != 3 {
+		t.Fatalf("Expected the cgroups to be set 3 times, got %d", len(set))
+	}
+	if last := set[2]; last.Memory != 64<<20 || last.MemorySwap != 128<<20 || last.CpusetCpus != "0" {
+		t.Fatalf("Expected the previous cgroups to be written back, got %+v", last)
+	}
+	if config.Cgroups.Memory != 64<<20 || config.Cgroups.CpusetCpus != "0" {
+		t.Fatalf("Expected the config to be left unchanged, got %+v", config.Cgroups)
+	}
+
+	// lowered limits are restored by raising the memory+swap limit first
+	set = nil
+	c.Resources = &Resources{Memory: 16 << 20, MemorySwap: 32 << 20, CpusetCpus: "7"}
+	if err := UpdateCgroups(config, c, func(config *configs.Config) error {
+		set = append(set, *config.Cgroups)
+		if config.Cgroups.CpusetCpus == "7" {
+			return errors.New("invalid argument")
+		}
+		return nil
+	}); err == nil {
+		t.Fatal("Expected the update to fail")
+	}
+	if len(set) != 3 || set[1].Memory != 0 || set[1].MemorySwap != 128<<20 || set[2].Memory != 64<<20 {
+		t.Fatalf("Unexpected cgroups %+v", set)
+	}
+}
+
+func TestSetupCgroups(t *testing.T) {
+	config := &configs.Config{Cgroups: &configs.Cgroup{}}
+	throttle := []*configs.ThrottleDevice{{Major: 8, Minor: 0, Rate: 1 << 20}}
+	c := &Command{Resources: &Resources{
+		Memory:             256 << 20,
+		CpuPeriod:          50000,
+		CpuQuota:           25000,
+		BlkioWeight:        300,
+		BlkioDeviceReadBps: throttle,
+		KernelMemory:       32 << 20,
+		OomKillDisable:     true,
+	}}
+	if err := SetupCgroups(config, c); err != nil {
+		t.Fatal(err)
+	}
+	cgroup := config.Cgroups
+	if cgroup.CpuPeriod != 50000 || cgroup.CpuQuota != 25000 || cgroup.BlkioWeight != 300 {
+		t.Fatalf("Unexpected cgroups %+v", cgroup)
+	}
+	if cgroup.KernelMemory != 32<<20 || !cgroup.OomKillDisable || len(cgroup.BlkioThrottleReadBpsDevice) != 1 {
+		t.Fatalf("Unexpected cgroups %+v", cgroup)
+	}
+	// the reservation defaults to the memory limit
+	if cgroup.MemoryReservation != 256<<20 {
+		t.Fatalf("Expected the memory reservation to be the memory limit, got %d", cgroup.MemoryReservation)
+	}
+	if s := cgroup.BlkioThrottleReadBpsDevice[0].String(); s != "8:0 1048576" {
+		t.Fatalf("Unexpected device rate limit %q", s)
+	}
+
+	c.Resources.MemoryReservation = 64 << 20
+	if err := SetupCgroups(config, c); err != nil {
+		t.Fatal(err)
+	}
+	if cgroup.MemoryReservation != 64<<20 {
+		t.Fatalf("Expected a memory reservation of 64MB, got %d", cgroup.MemoryReservation)
+	}
+}
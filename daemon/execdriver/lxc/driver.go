@@ -24,6 +24,7 @@ import (
 	"github.com/docker/docker/utils"
 	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/cgroups/fs"
 	"github.com/docker/libcontainer/configs"
 	"github.com/docker/libcontainer/system"
 	"github.com/docker/libcontainer/user"
@@ -488,6 +489,30 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return err
 }
 
+func (d *driver) Update(c *execdriver.Command) error {
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return execdriver.ErrNotRunning
+	}
+	paths, err := cgroupPaths(c.ID)
+	if err != nil {
+		return err
+	}
+	config := *active.container
+	if err := execdriver.UpdateCgroups(&config, c, func(config *configs.Config) error {
+		mgr := fs.Manager{Cgroups: config.Cgroups, Paths: paths}
+		return mgr.Set(config)
+	}); err != nil {
+		return err
+	}
+	d.Lock()
+	active.container = &config
+	d.Unlock()
+	return nil
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	return KillLxc(c.ID, 9)
 }
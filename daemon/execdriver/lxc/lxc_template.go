@@ -95,21 +95,60 @@ lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabS
 {{end}}
 {{end}}
 
+{{range $dest, $options := .Tmpfs}}
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $dest}} tmpfs nosuid,nodev,noexec{{if $options}},{{$options}}{{end}},create=dir 0 0
+{{end}}
+
 # limits
 {{if .Resources}}
+{{if .Resources.KernelMemory}}
+lxc.cgroup.memory.kmem.limit_in_bytes = {{.Resources.KernelMemory}}
+{{end}}
 {{if .Resources.Memory}}
 lxc.cgroup.memory.limit_in_bytes = {{.Resources.Memory}}
+{{if not .Resources.MemoryReservation}}
 lxc.cgroup.memory.soft_limit_in_bytes = {{.Resources.Memory}}
+{{end}}
 {{with $memSwap := getMemorySwap .Resources}}
 lxc.cgroup.memory.memsw.limit_in_bytes = {{$memSwap}}
 {{end}}
 {{end}}
+{{if .Resources.MemoryReservation}}
+lxc.cgroup.memory.soft_limit_in_bytes = {{.Resources.MemoryReservation}}
+{{end}}
+{{if .Resources.OomKillDisable}}
+lxc.cgroup.memory.oom_control = 1
+{{end}}
 {{if .Resources.CpuShares}}
 lxc.cgroup.cpu.shares = {{.Resources.CpuShares}}
 {{end}}
+{{if .Resources.CpuPeriod}}
+lxc.cgroup.cpu.cfs_period_us = {{.Resources.CpuPeriod}}
+{{end}}
+{{if .Resources.CpuQuota}}
+lxc.cgroup.cpu.cfs_quota_us = {{.Resources.CpuQuota}}
+{{end}}
 {{if .Resources.CpusetCpus}}
 lxc.cgroup.cpuset.cpus = {{.Resources.CpusetCpus}}
 {{end}}
+{{if .Resources.PidsLimit}}
+lxc.cgroup.pids.max = {{.Resources.PidsLimit}}
+{{end}}
+{{if .Resources.BlkioWeight}}
+lxc.cgroup.blkio.weight = {{.Resources.BlkioWeight}}
+{{end}}
+{{range .Resources.BlkioDeviceReadBps}}
+lxc.cgroup.blkio.throttle.read_bps_device = {{.}}
+{{end}}
+{{range .Resources.BlkioDeviceWriteBps}}
+lxc.cgroup.blkio.throttle.write_bps_device = {{.}}
+{{end}}
+{{range .Resources.BlkioDeviceReadIOps}}
+lxc.cgroup.blkio.throttle.read_iops_device = {{.}}
+{{end}}
+{{range .Resources.BlkioDeviceWriteIOps}}
+lxc.cgroup.blkio.throttle.write_iops_device = {{.}}
+{{end}}
 {{end}}
 
 {{if .LxcConfig}}
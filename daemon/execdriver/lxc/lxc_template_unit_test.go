@@ -67,6 +67,49 @@ func TestLXCConfig(t *testing.T) {
 		fmt.Sprintf("lxc.cgroup.memory.memsw.limit_in_bytes = %d", mem*2))
 }
 
+func TestLXCConfigResources(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigResources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Resources: &execdriver.Resources{
+			Memory:              256 << 20,
+			MemoryReservation:   64 << 20,
+			CpuPeriod:           50000,
+			CpuQuota:            25000,
+			BlkioWeight:         300,
+			BlkioDeviceWriteBps: []*configs.ThrottleDevice{{Major: 8, Minor: 0, Rate: 1048576}},
+			OomKillDisable:      true,
+		},
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		AllowedDevices: make([]*configs.Device, 0),
+		ProcessConfig:  execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, fmt.Sprintf("lxc.cgroup.memory.soft_limit_in_bytes = %d", 64<<20))
+	grepFile(t, p, "lxc.cgroup.memory.oom_control = 1")
+	grepFile(t, p, "lxc.cgroup.cpu.cfs_period_us = 50000")
+	grepFile(t, p, "lxc.cgroup.cpu.cfs_quota_us = 25000")
+	grepFile(t, p, "lxc.cgroup.blkio.weight = 300")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.write_bps_device = 8:0 1048576")
+}
+
 func TestCustomLxcConfig(t *testing.T) {
 	root, err := ioutil.TempDir("", "TestCustomLxcConfig")
 	if err != nil {
@@ -226,6 +269,7 @@ func TestCustomLxcConfigMounts(t *testing.T) {
 			Interface: nil,
 		},
 		Mounts:        mounts,
+		Tmpfs:         map[string]string{"/run": "", "/tmp": "size=64m,mode=1777"},
 		ProcessConfig: processConfig,
 	}
 
@@ -239,6 +283,8 @@ func TestCustomLxcConfigMounts(t *testing.T) {
 
 	grepFile(t, p, fmt.Sprintf("lxc.mount.entry = %s %s none rbind,ro,create=%s 0 0", tempDir, "/"+tempDir, "dir"))
 	grepFile(t, p, fmt.Sprintf("lxc.mount.entry = %s %s none rbind,rw,create=%s 0 0", tempFile.Name(), "/"+tempFile.Name(), "file"))
+	grepFile(t, p, "lxc.mount.entry = tmpfs //run tmpfs nosuid,nodev,noexec,create=dir 0 0")
+	grepFile(t, p, "lxc.mount.entry = tmpfs //tmp tmpfs nosuid,nodev,noexec,size=64m,mode=1777,create=dir 0 0")
 }
 
 func TestCustomLxcConfigMisc(t *testing.T) {
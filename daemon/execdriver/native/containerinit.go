@@ -0,0 +1,93 @@
+// +build linux
+
+package native
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/term"
+)
+
+// ContainerInitPath is where dockerinit is mounted in the containers run with
+// --init, to run their command as a minimal init
+const ContainerInitPath = "/dev/init"
+
+func init() {
+	reexec.Register(ContainerInitPath, containerInitializer)
+}
+
+// containerInitializer runs as the pid 1 of a container the command given as
+// its arguments. It forwards the signals it receives to the command, reaps
+// the processes reparented to it, and exits with the status of the command.
+func containerInitializer() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "dockerinit: no command to run")
+		os.Exit(127)
+	}
+	path, err := exec.LookPath(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dockerinit: unable to locate %s\n", os.Args[1])
+		os.Exit(127)
+	}
+
+	// the signals received before the command starts are forwarded to it
+	signals := make(chan os.Signal, 32)
+	signal.Notify(signals)
+
+	cmd := &exec.Cmd{
+		Path:        path,
+		Args:        os.Args[1:],
+		Env:         os.Environ(),
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		SysProcAttr: &syscall.SysProcAttr{Setsid: true},
+	}
+	if term.IsTerminal(os.Stdin.Fd()) {
+		// The terminal is given to the session of the command, so that the
+		// signals of the terminal, like ^C or the resizes, are only sent
+		// to it. Releasing the terminal sends SIGHUP to the init.
+		signal.Ignore(syscall.SIGHUP)
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_IOCTL, os.Stdin.Fd(), syscall.TIOCNOTTY, 0); errno != 0 {
+			fmt.Fprintf(os.Stderr, "dockerinit: unable to release the terminal: %v\n", errno)
+			os.Exit(126)
+		}
+		signal.Notify(signals, syscall.SIGHUP)
+		cmd.SysProcAttr.Setctty = true
+		cmd.SysProcAttr.Ctty = 0
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "dockerinit: unable to execute %s: %v\n", path, err)
+		os.Exit(126)
+	}
+
+	for sig := range signals {
+		if sig != syscall.SIGCHLD {
+			cmd.Process.Signal(sig)
+			continue
+		}
+		for {
+			var status syscall.WaitStatus
+			pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+			if err != nil || pid <= 0 {
+				break
+			}
+			if pid == cmd.Process.Pid {
+				os.Exit(exitCode(status))
+			}
+		}
+	}
+}
+
+// exitCode returns the exit code of a shell for a process of the given status
+func exitCode(status syscall.WaitStatus) int {
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return status.ExitStatus()
+}
@@ -0,0 +1,59 @@
+// +build linux
+
+package native
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func init() {
+	reexec.Init()
+}
+
+func runContainerInit(t *testing.T, sig syscall.Signal, args ...string) int {
+	cmd := &exec.Cmd{
+		Path: reexec.Self(),
+		Args: append([]string{ContainerInitPath}, args...),
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if sig != 0 {
+		time.Sleep(100 * time.Millisecond)
+		if err := cmd.Process.Signal(sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := cmd.Wait()
+	if err == nil {
+		return 0
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatal(err)
+	}
+	return exitErr.Sys().(syscall.WaitStatus).ExitStatus()
+}
+
+func TestContainerInitExitCode(t *testing.T) {
+	if code := runContainerInit(t, 0, "sh", "-c", "exit 3"); code != 3 {
+		t.Fatalf("Expected exit code 3, got %d", code)
+	}
+	if code := runContainerInit(t, 0, "/nonexistent"); code != 127 {
+		t.Fatalf("Expected exit code 127, got %d", code)
+	}
+}
+
+func TestContainerInitForwardsSignals(t *testing.T) {
+	if code := runContainerInit(t, syscall.SIGTERM, "sleep", "10"); code != 128+int(syscall.SIGTERM) {
+		t.Fatalf("Expected exit code %d, got %d", 128+int(syscall.SIGTERM), code)
+	}
+	if code := runContainerInit(t, syscall.SIGUSR1, "sh", "-c", "trap 'exit 5' USR1; while :; do sleep 0.01; done"); code != 5 {
+		t.Fatalf("Expected exit code 5, got %d", code)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 
 	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/libcontainer/apparmor"
 	"github.com/docker/libcontainer/configs"
@@ -31,6 +33,10 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		return nil, err
 	}
 
+	if err := d.createUserns(container, c); err != nil {
+		return nil, err
+	}
+
 	if err := d.createNetwork(container, c); err != nil {
 		return nil, err
 	}
@@ -57,6 +63,14 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		container.AppArmorProfile = c.AppArmorProfile
 	}
 
+	if err := d.setSeccomp(container, c); err != nil {
+		return nil, err
+	}
+	container.NoNewPrivileges = c.NoNewPrivileges
+
+	// set in the namespaces of the container by its init
+	container.Sysctl = c.Sysctls
+
 	if err := execdriver.SetupCgroups(container, c); err != nil {
 		return nil, err
 	}
@@ -65,6 +79,15 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		return nil, err
 	}
 
+	if c.Init {
+		container.Mounts = append(container.Mounts, &configs.Mount{
+			Source:      d.initPath,
+			Destination: ContainerInitPath,
+			Device:      "bind",
+			Flags:       syscall.MS_BIND | syscall.MS_RDONLY,
+		})
+	}
+
 	if err := d.setupLabels(container, c); err != nil {
 		return nil, err
 	}
@@ -176,6 +199,46 @@ func (d *driver) createPid(container *configs.Config, c *execdriver.Command) err
 	return nil
 }
 
+func (d *driver) createUserns(container *configs.Config, c *execdriver.Command) error {
+	if len(c.UIDMapping) == 0 {
+		return nil
+	}
+
+	container.Namespaces.Add(configs.NEWUSER, "")
+	for _, m := range c.UIDMapping {
+		container.UidMappings = append(container.UidMappings, configs.IDMap{
+			ContainerID: m.ContainerID,
+			HostID:      m.HostID,
+			Size:        m.Size,
+		})
+	}
+	for _, m := range c.GIDMapping {
+		container.GidMappings = append(container.GidMappings, configs.IDMap{
+			ContainerID: m.ContainerID,
+			HostID:      m.HostID,
+			Size:        m.Size,
+		})
+	}
+
+	return nil
+}
+
+func (d *driver) setSeccomp(container *configs.Config, c *execdriver.Command) error {
+	switch c.SeccompProfile {
+	case "", "unconfined":
+		return nil
+	case "default":
+		container.Seccomp = defaultSeccompProfile(container.Capabilities)
+		return nil
+	}
+	profile, err := execdriver.LoadSeccompProfile(c.SeccompProfile)
+	if err != nil {
+		return err
+	}
+	container.Seccomp = profile
+	return nil
+}
+
 func (d *driver) setPrivileged(container *configs.Config) (err error) {
 	container.Capabilities = execdriver.GetAllCapabilities()
 	container.Cgroups.AllowAllDevices = true
@@ -217,6 +280,9 @@ func (d *driver) setupMounts(container *configs.Config, c *execdriver.Command) e
 	for _, m := range c.Mounts {
 		userMounts[m.Destination] = struct{}{}
 	}
+	for dest := range c.Tmpfs {
+		userMounts[dest] = struct{}{}
+	}
 
 	// Filter out mounts that are overriden by user supplied mounts
 	var defaultMounts []*configs.Mount
@@ -247,6 +313,35 @@ func (d *driver) setupMounts(container *configs.Config, c *execdriver.Command) e
 			Flags:       flags,
 		})
 	}
+
+	// The tmpfs are mounted in the order of their destination, so that a
+	// tmpfs under another one isn't hidden by it
+	var tmpfs []string
+	for dest := range c.Tmpfs {
+		tmpfs = append(tmpfs, dest)
+	}
+	sort.Strings(tmpfs)
+	for _, t := range tmpfs {
+		dest, err := symlink.FollowSymlinkInScope(filepath.Join(c.Rootfs, t), c.Rootfs)
+		if err != nil {
+			return err
+		}
+		options := "noexec,nosuid,nodev"
+		if c.Tmpfs[t] != "" {
+			options += "," + c.Tmpfs[t]
+		}
+		flags, data, err := mount.ParseTmpfsOptions(options)
+		if err != nil {
+			return err
+		}
+		container.Mounts = append(container.Mounts, &configs.Mount{
+			Source:      "tmpfs",
+			Destination: dest,
+			Device:      "tmpfs",
+			Flags:       flags,
+			Data:        data,
+		})
+	}
 	return nil
 }
 
@@ -3,6 +3,7 @@
 package native
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -98,6 +100,9 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		Cwd:  c.WorkingDir,
 		User: c.ProcessConfig.User,
 	}
+	if c.Init {
+		p.Args = append([]string{ContainerInitPath}, p.Args...)
+	}
 
 	if c.ProcessConfig.Tty {
 		rootuid, err := container.HostUID()
@@ -268,6 +273,19 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return active.Resume()
 }
 
+func (d *driver) Update(c *execdriver.Command) error {
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return execdriver.ErrNotRunning
+	}
+	config := active.Config()
+	return execdriver.UpdateCgroups(&config, c, func(config *configs.Config) error {
+		return active.Set(*config)
+	})
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	defer d.cleanContainer(c.ID)
 	container, err := d.factory.Load(c.ID)
@@ -352,11 +370,41 @@ func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	if memoryLimit == 0 {
 		memoryLimit = d.machineMemory
 	}
-	return &execdriver.ResourceStats{
+	rs := &execdriver.ResourceStats{
 		Stats:       stats,
 		Read:        now,
 		MemoryLimit: memoryLimit,
-	}, nil
+	}
+	if pids, err := c.Processes(); err == nil {
+		rs.Pids = uint64(len(pids))
+	}
+	if state, err := c.State(); err == nil {
+		if rs.OomKills, err = oomKills(state.CgroupPaths["memory"]); err != nil {
+			log.Debugf("reading oom kills of %s: %v", id, err)
+		}
+	}
+	return rs, nil
+}
+
+// oomKills returns the oom_kill counter of the memory cgroup at path, older
+// kernels do not have it and report 0.
+func oomKills(path string) (uint64, error) {
+	if path == "" {
+		return 0, nil
+	}
+	f, err := os.Open(filepath.Join(path, "memory.oom_control"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		parts := strings.Fields(sc.Text())
+		if len(parts) == 2 && parts[0] == "oom_kill" {
+			return strconv.ParseUint(parts[1], 10, 64)
+		}
+	}
+	return 0, sc.Err()
 }
 
 func getEnv(key string, env []string) string {
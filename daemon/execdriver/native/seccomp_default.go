@@ -0,0 +1,94 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"sort"
+	"syscall"
+
+	"github.com/docker/libcontainer/configs"
+)
+
+// blockedSyscalls are failed with EPERM by the default seccomp profile. They
+// manage the host or its kernel, or expose kernel interfaces that containers
+// don't need.
+var blockedSyscalls = []string{
+	"_sysctl",
+	"add_key",
+	"create_module",
+	"get_kernel_syms",
+	"keyctl",
+	"nfsservctl",
+	"query_module",
+	"request_key",
+	"sysfs",
+	"uselib",
+	"userfaultfd",
+	"ustat",
+	"vm86",
+	"vm86old",
+}
+
+// capabilitySyscalls are also failed with EPERM by the default seccomp
+// profile, unless the container has the capability they require, e.g. given
+// with --cap-add.
+var capabilitySyscalls = map[string][]string{
+	"DAC_READ_SEARCH": {"open_by_handle_at"},
+	"SYS_ADMIN": {
+		"bpf",
+		"lookup_dcookie",
+		"mount",
+		"name_to_handle_at",
+		"perf_event_open",
+		"pivot_root",
+		"quotactl",
+		"setns",
+		"swapoff",
+		"swapon",
+		"umount",
+		"umount2",
+		"unshare",
+	},
+	"SYS_BOOT":   {"kexec_file_load", "kexec_load", "reboot"},
+	"SYS_MODULE": {"delete_module", "finit_module", "init_module"},
+	"SYS_NICE":   {"get_mempolicy", "mbind", "move_pages", "set_mempolicy"},
+	"SYS_PACCT":  {"acct"},
+	"SYS_PTRACE": {"kcmp", "process_vm_readv", "process_vm_writev", "ptrace"},
+	"SYS_RAWIO":  {"ioperm", "iopl"},
+	"SYS_TIME":   {"adjtimex", "clock_adjtime", "clock_settime", "settimeofday", "stime"},
+}
+
+// defaultSeccompProfile returns the seccomp filter applied to containers with
+// the given capabilities, unless they are privileged or given another profile
+func defaultSeccompProfile(capabilities []string) *configs.Seccomp {
+	granted := make(map[string]bool)
+	for _, c := range capabilities {
+		granted[c] = true
+	}
+	blocked := append([]string{}, blockedSyscalls...)
+	for c, names := range capabilitySyscalls {
+		if !granted[c] {
+			blocked = append(blocked, names...)
+		}
+	}
+	sort.Strings(blocked)
+
+	profile := &configs.Seccomp{DefaultAction: configs.Allow}
+	for _, name := range blocked {
+		profile.Syscalls = append(profile.Syscalls, &configs.Syscall{Name: name, Action: configs.Errno})
+	}
+	if !granted["SYS_ADMIN"] {
+		// new user namespaces can't be created either
+		profile.Syscalls = append(profile.Syscalls, &configs.Syscall{
+			Name:   "clone",
+			Action: configs.Errno,
+			Args: []*configs.Arg{{
+				Index:    0,
+				Value:    syscall.CLONE_NEWUSER,
+				ValueTwo: syscall.CLONE_NEWUSER,
+				Op:       configs.MaskEqualTo,
+			}},
+		})
+	}
+	return profile
+}
@@ -0,0 +1,39 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"testing"
+
+	"github.com/docker/libcontainer/configs"
+)
+
+func blockedBy(profile *configs.Seccomp) map[string]bool {
+	blocked := make(map[string]bool)
+	for _, s := range profile.Syscalls {
+		blocked[s.Name] = true
+	}
+	return blocked
+}
+
+func TestDefaultSeccompProfileCapabilities(t *testing.T) {
+	blocked := blockedBy(defaultSeccompProfile([]string{"CHOWN", "KILL"}))
+	for _, name := range []string{"mount", "umount2", "unshare", "setns", "ptrace", "perf_event_open", "reboot", "keyctl", "clone"} {
+		if !blocked[name] {
+			t.Fatalf("Expected %s to be blocked without capabilities", name)
+		}
+	}
+
+	blocked = blockedBy(defaultSeccompProfile([]string{"CHOWN", "KILL", "SYS_ADMIN", "SYS_PTRACE"}))
+	for _, name := range []string{"mount", "umount2", "unshare", "setns", "clone", "ptrace", "process_vm_readv", "perf_event_open"} {
+		if blocked[name] {
+			t.Fatalf("Expected %s to be allowed with SYS_ADMIN and SYS_PTRACE", name)
+		}
+	}
+	// the syscalls of other capabilities, or of none, are still blocked
+	for _, name := range []string{"reboot", "init_module", "settimeofday", "keyctl"} {
+		if !blocked[name] {
+			t.Fatalf("Expected %s to be blocked with SYS_ADMIN and SYS_PTRACE", name)
+		}
+	}
+}
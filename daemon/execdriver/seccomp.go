@@ -0,0 +1,93 @@
+// +build linux,cgo
+
+package execdriver
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/libcontainer/configs"
+)
+
+// seccompProfile is the JSON format of the custom seccomp profiles given by
+// --security-opt seccomp=PROFILE
+type seccompProfile struct {
+	DefaultAction string            `json:"defaultAction"`
+	Syscalls      []*seccompSyscall `json:"syscalls"`
+}
+
+type seccompSyscall struct {
+	Name   string        `json:"name"`
+	Action string        `json:"action"`
+	Args   []*seccompArg `json:"args"`
+}
+
+type seccompArg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo"`
+	Op       string `json:"op"`
+}
+
+var seccompActions = map[string]configs.Action{
+	"SCMP_ACT_KILL":  configs.Kill,
+	"SCMP_ACT_ERRNO": configs.Errno,
+	"SCMP_ACT_TRAP":  configs.Trap,
+	"SCMP_ACT_ALLOW": configs.Allow,
+}
+
+var seccompOperators = map[string]configs.Operator{
+	"SCMP_CMP_NE":        configs.NotEqualTo,
+	"SCMP_CMP_LT":        configs.LessThan,
+	"SCMP_CMP_LE":        configs.LessThanOrEqualTo,
+	"SCMP_CMP_EQ":        configs.EqualTo,
+	"SCMP_CMP_GE":        configs.GreaterThanOrEqualTo,
+	"SCMP_CMP_GT":        configs.GreaterThan,
+	"SCMP_CMP_MASKED_EQ": configs.MaskEqualTo,
+}
+
+// LoadSeccompProfile converts the JSON seccomp profile body to the filter of
+// the container, which the native driver applies.
+func LoadSeccompProfile(body string) (*configs.Seccomp, error) {
+	var profile seccompProfile
+	if err := json.Unmarshal([]byte(body), &profile); err != nil {
+		return nil, fmt.Errorf("Invalid seccomp profile: %v", err)
+	}
+
+	defaultAction, ok := seccompActions[profile.DefaultAction]
+	if !ok {
+		return nil, fmt.Errorf("Invalid seccomp profile: unknown default action %q", profile.DefaultAction)
+	}
+	config := &configs.Seccomp{DefaultAction: defaultAction}
+	for _, s := range profile.Syscalls {
+		action, ok := seccompActions[s.Action]
+		if !ok {
+			return nil, fmt.Errorf("Invalid seccomp profile: unknown action %q of syscall %s", s.Action, s.Name)
+		}
+		syscall := &configs.Syscall{Name: s.Name, Action: action}
+		for _, a := range s.Args {
+			op, ok := seccompOperators[a.Op]
+			if !ok {
+				return nil, fmt.Errorf("Invalid seccomp profile: unknown operator %q for syscall %s", a.Op, s.Name)
+			}
+			if a.Index > 5 {
+				return nil, fmt.Errorf("Invalid seccomp profile: invalid argument index %d for syscall %s", a.Index, s.Name)
+			}
+			syscall.Args = append(syscall.Args, &configs.Arg{
+				Index:    a.Index,
+				Value:    a.Value,
+				ValueTwo: a.ValueTwo,
+				Op:       op,
+			})
+		}
+		config.Syscalls = append(config.Syscalls, syscall)
+	}
+	return config, nil
+}
+
+// ValidateSeccompProfile checks the JSON seccomp profile body, given to a
+// container with --security-opt seccomp=PROFILE, can be loaded
+func ValidateSeccompProfile(body string) error {
+	_, err := LoadSeccompProfile(body)
+	return err
+}
@@ -0,0 +1,40 @@
+// +build linux,cgo
+
+package execdriver
+
+import (
+	"testing"
+
+	"github.com/docker/libcontainer/configs"
+)
+
+func TestLoadSeccompProfile(t *testing.T) {
+	profile, err := LoadSeccompProfile(`{
+		"defaultAction": "SCMP_ACT_ERRNO",
+		"syscalls": [
+			{"name": "read", "action": "SCMP_ACT_ALLOW"},
+			{"name": "personality", "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "value": 8, "op": "SCMP_CMP_EQ"}]}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.DefaultAction != configs.Errno || len(profile.Syscalls) != 2 {
+		t.Fatalf("Unexpected profile %+v", profile)
+	}
+	if s := profile.Syscalls[1]; s.Name != "personality" || s.Action != configs.Allow || len(s.Args) != 1 || s.Args[0].Value != 8 || s.Args[0].Op != configs.EqualTo {
+		t.Fatalf("Unexpected syscall %+v", s)
+	}
+
+	for _, body := range []string{
+		`{"defaultAction": "SCMP_ACT_ALLOW"`,
+		`{"defaultAction": "ALLOW"}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "read", "action": "SCMP_ACT_LOG"}]}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "read", "action": "SCMP_ACT_KILL", "args": [{"index": 0, "op": "SCMP_CMP_IN"}]}]}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "read", "action": "SCMP_ACT_KILL", "args": [{"index": 6, "op": "SCMP_CMP_EQ"}]}]}`,
+	} {
+		if _, err := LoadSeccompProfile(body); err == nil {
+			t.Fatalf("Expected the profile %s to be invalid", body)
+		}
+	}
+}
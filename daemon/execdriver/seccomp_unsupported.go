@@ -0,0 +1,10 @@
+// +build !linux !cgo
+
+package execdriver
+
+import "fmt"
+
+// ValidateSeccompProfile fails, seccomp profiles are only supported on linux
+func ValidateSeccompProfile(body string) error {
+	return fmt.Errorf("Seccomp profiles are not supported on this platform")
+}
@@ -17,6 +17,7 @@ import (
 	"unsafe"
 
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
 )
 
@@ -24,7 +25,7 @@ func init() {
 	graphdriver.Register("btrfs", Init)
 }
 
-func Init(home string, options []string) (graphdriver.Driver, error) {
+func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (graphdriver.Driver, error) {
 	rootdir := path.Dir(home)
 
 	var buf syscall.Statfs_t
@@ -36,7 +37,11 @@ func Init(home string, options []string) (graphdriver.Driver, error) {
 		return nil, graphdriver.ErrPrerequisites
 	}
 
-	if err := os.MkdirAll(home, 0700); err != nil {
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	if err := idtools.MkdirAllAs(home, 0700, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
@@ -45,14 +50,19 @@ func Init(home string, options []string) (graphdriver.Driver, error) {
 	}
 
 	driver := &Driver{
-		home: home,
+		home:    home,
+		rootUID: rootUID,
+		rootGID: rootGID,
 	}
 
-	return graphdriver.NaiveDiffDriver(driver), nil
+	return graphdriver.NaiveDiffDriver(driver, uidMaps, gidMaps), nil
 }
 
 type Driver struct {
 	home string
+	// the owner of the root directory of the subvolumes
+	rootUID int
+	rootGID int
 }
 
 func (d *Driver) String() string {
@@ -176,13 +186,16 @@ func (d *Driver) subvolumesDirId(id string) string {
 
 func (d *Driver) Create(id string, parent string) error {
 	subvolumes := path.Join(d.home, "subvolumes")
-	if err := os.MkdirAll(subvolumes, 0700); err != nil {
+	if err := idtools.MkdirAllAs(subvolumes, 0700, d.rootUID, d.rootGID); err != nil {
 		return err
 	}
 	if parent == "" {
 		if err := subvolCreate(subvolumes, id); err != nil {
 			return err
 		}
+		if err := os.Chown(path.Join(subvolumes, id), d.rootUID, d.rootGID); err != nil {
+			return err
+		}
 	} else {
 		parentDir, err := d.Get(parent, "")
 		if err != nil {
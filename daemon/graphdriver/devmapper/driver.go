@@ -11,6 +11,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/devicemapper"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/units"
 )
@@ -27,11 +28,22 @@ func init() {
 type Driver struct {
 	*DeviceSet
 	home string
+	// the owner of the root directory of the devices
+	rootUID int
+	rootGID int
 }
 
 var backingFs = "<unknown>"
 
-func Init(home string, options []string) (graphdriver.Driver, error) {
+func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (graphdriver.Driver, error) {
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	if err := idtools.MkdirAllAs(home, 0700, rootUID, rootGID); err != nil {
+		return nil, err
+	}
+
 	fsMagic, err := graphdriver.GetFSMagic(home)
 	if err != nil {
 		return nil, err
@@ -52,9 +64,11 @@ func Init(home string, options []string) (graphdriver.Driver, error) {
 	d := &Driver{
 		DeviceSet: deviceSet,
 		home:      home,
+		rootUID:   rootUID,
+		rootGID:   rootGID,
 	}
 
-	return graphdriver.NaiveDiffDriver(d), nil
+	return graphdriver.NaiveDiffDriver(d, uidMaps, gidMaps), nil
 }
 
 func (d *Driver) String() string {
@@ -133,7 +147,7 @@ func (d *Driver) Get(id, mountLabel string) (string, error) {
 	mp := path.Join(d.home, "mnt", id)
 
 	// Create the target directories if they don't exist
-	if err := os.MkdirAll(mp, 0755); err != nil && !os.IsExist(err) {
+	if err := idtools.MkdirAllAs(mp, 0755, d.rootUID, d.rootGID); err != nil && !os.IsExist(err) {
 		return "", err
 	}
 
@@ -143,7 +157,7 @@ func (d *Driver) Get(id, mountLabel string) (string, error) {
 	}
 
 	rootFs := path.Join(mp, "rootfs")
-	if err := os.MkdirAll(rootFs, 0755); err != nil && !os.IsExist(err) {
+	if err := idtools.MkdirAllAs(rootFs, 0755, d.rootUID, d.rootGID); err != nil && !os.IsExist(err) {
 		d.DeviceSet.UnmountDevice(id)
 		return "", err
 	}
@@ -177,3 +191,10 @@ func (d *Driver) DataUsePercent() float64 {
 	s := d.DeviceSet.Status()
 	return float64(s.Data.Used)/float64(s.Data.Total)
 }
+
+// ThinPoolUsage returns the used and total bytes of the data and metadata
+// spaces of the thin pool
+func (d *Driver) ThinPoolUsage() (dataUsed, dataTotal, metadataUsed, metadataTotal uint64) {
+	s := d.DeviceSet.Status()
+	return s.Data.Used, s.Data.Total, s.Metadata.Used, s.Metadata.Total
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/libcontainer/label"
 )
 
@@ -14,15 +15,24 @@ func init() {
 	graphdriver.Register("vfs", Init)
 }
 
-func Init(home string, options []string) (graphdriver.Driver, error) {
+func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (graphdriver.Driver, error) {
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
 	d := &Driver{
-		home: home,
+		home:    home,
+		rootUID: rootUID,
+		rootGID: rootGID,
 	}
-	return graphdriver.NaiveDiffDriver(d), nil
+	return graphdriver.NaiveDiffDriver(d, uidMaps, gidMaps), nil
 }
 
 type Driver struct {
 	home string
+	// the owner of the root directory of the layers
+	rootUID int
+	rootGID int
 }
 
 func (d *Driver) String() string {
@@ -39,12 +49,15 @@ func (d *Driver) Cleanup() error {
 
 func (d *Driver) Create(id, parent string) error {
 	dir := d.dir(id)
-	if err := os.MkdirAll(path.Dir(dir), 0700); err != nil {
+	if err := idtools.MkdirAllAs(path.Dir(dir), 0700, d.rootUID, d.rootGID); err != nil {
 		return err
 	}
 	if err := os.Mkdir(dir, 0755); err != nil {
 		return err
 	}
+	if err := os.Chown(dir, d.rootUID, d.rootGID); err != nil {
+		return err
+	}
 	opts := []string{"level:s0"}
 	if _, mountLabel, err := label.InitLabels(opts); err == nil {
 		label.SetFileLabel(dir, mountLabel)
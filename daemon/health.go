@@ -0,0 +1,255 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/common"
+	"github.com/docker/docker/runconfig"
+)
+
+// Health status of a container with a health check
+const (
+	HealthStarting = "starting"
+	Healthy        = "healthy"
+	Unhealthy      = "unhealthy"
+)
+
+const (
+	defaultProbeInterval = 30 * time.Second
+	defaultProbeTimeout  = 30 * time.Second
+	defaultProbeRetries  = 3
+
+	// maxHealthLogEntries is the number of check results kept in Health.Log
+	maxHealthLogEntries = 5
+	// maxHealthOutput is the number of bytes kept of the output of a check
+	maxHealthOutput = 4096
+)
+
+// Health is the health of a container which has a health check
+type Health struct {
+	Status        string // HealthStarting, Healthy or Unhealthy
+	FailingStreak int    // Number of consecutive failed checks
+	Log           []*HealthcheckResult
+
+	// stop is closed to stop the checks, it is nil when they don't run
+	stop chan struct{}
+}
+
+// HealthcheckResult is the result of a single health check
+type HealthcheckResult struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int // 0 is healthy, -1 means the check could not run or timed out
+	Output   string
+}
+
+// healthString returns the health of a running container for its status,
+// e.g. " (healthy)"
+func (s *State) healthString() string {
+	if s.Health == nil {
+		return ""
+	}
+	if s.Health.Status == HealthStarting {
+		return " (health: starting)"
+	}
+	return fmt.Sprintf(" (%s)", s.Health.Status)
+}
+
+// stopHealthcheck stops the health checks, the state must be locked. The
+// last status is kept for inspect.
+func (s *State) stopHealthcheck() {
+	if s.Health != nil && s.Health.stop != nil {
+		close(s.Health.stop)
+		s.Health.stop = nil
+	}
+}
+
+// updateHealth records the result of a check run by the checks stopped by
+// stop, and returns the resulting status and whether it changed
+func (s *State) updateHealth(result *HealthcheckResult, stop chan struct{}, retries int) (string, bool) {
+	s.Lock()
+	defer s.Unlock()
+	h := s.Health
+	if h == nil || h.stop != stop {
+		// the checks were stopped while this one ran
+		return "", false
+	}
+	h.Log = append(h.Log, result)
+	if len(h.Log) > maxHealthLogEntries {
+		h.Log = h.Log[len(h.Log)-maxHealthLogEntries:]
+	}
+	old := h.Status
+	if result.ExitCode == 0 {
+		h.FailingStreak = 0
+		h.Status = Healthy
+	} else if h.FailingStreak++; h.FailingStreak >= retries {
+		h.Status = Unhealthy
+	}
+	return h.Status, h.Status != old
+}
+
+// healthcheckConfig returns the health check of the container with the
+// defaults applied, or nil if it has none
+func (container *Container) healthcheckConfig() *runconfig.HealthConfig {
+	hc := container.Config.Healthcheck
+	if hc == nil || len(hc.Test) == 0 || hc.Test[0] == "NONE" {
+		return nil
+	}
+	config := *hc
+	if config.Interval == 0 {
+		config.Interval = defaultProbeInterval
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultProbeTimeout
+	}
+	if config.Retries == 0 {
+		config.Retries = defaultProbeRetries
+	}
+	return &config
+}
+
+// initHealthMonitor starts the health checks of the container, which must be
+// locked. unhealthy is called after each failed check of an unhealthy
+// container.
+func (container *Container) initHealthMonitor(unhealthy func()) {
+	container.stopHealthcheck()
+	config := container.healthcheckConfig()
+	if config == nil {
+		container.Health = nil
+		return
+	}
+	if container.Health == nil {
+		container.Health = &Health{}
+	}
+	container.Health.Status = HealthStarting
+	container.Health.FailingStreak = 0
+	stop := make(chan struct{})
+	container.Health.stop = stop
+	go container.monitorHealth(config, stop, unhealthy)
+}
+
+func (container *Container) monitorHealth(config *runconfig.HealthConfig, stop chan struct{}, unhealthy func()) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(config.Interval):
+		}
+		if container.IsPaused() {
+			// the check would hang until its timeout
+			continue
+		}
+		result := container.runHealthcheck(config, stop)
+		if result == nil {
+			return
+		}
+		status, changed := container.updateHealth(result, stop, config.Retries)
+		if changed {
+			container.LogEvent("health_status: " + status)
+		}
+		if status == Unhealthy && unhealthy != nil {
+			unhealthy()
+		}
+	}
+}
+
+// runHealthcheck runs the check of config in the container, like docker exec
+// does. It returns nil if the checks were stopped meanwhile.
+func (container *Container) runHealthcheck(config *runconfig.HealthConfig, stop chan struct{}) *HealthcheckResult {
+	cmd := config.Test[1:]
+	if config.Test[0] == "CMD-SHELL" {
+		cmd = append([]string{"/bin/sh", "-c"}, cmd...)
+	}
+	entrypoint, args := container.daemon.getEntrypointAndArgs(nil, cmd)
+	execConfig := &execConfig{
+		ID:         common.GenerateRandomID(),
+		OpenStdout: true,
+		OpenStderr: true,
+		ProcessConfig: execdriver.ProcessConfig{
+			Entrypoint: entrypoint,
+			Arguments:  args,
+		},
+		Container: container,
+		waitStart: make(chan struct{}),
+	}
+
+	var (
+		result = &HealthcheckResult{Start: time.Now()}
+		output = &limitedBuffer{}
+		pids   = make(chan int, 1)
+		done   = make(chan int, 1)
+	)
+	go func() {
+		pipes := execdriver.NewPipes(nil, output, output, false)
+		exitCode, err := container.daemon.Exec(container, execConfig, pipes, func(_ *execdriver.ProcessConfig, pid int) {
+			pids <- pid
+		})
+		if err != nil {
+			output.Write([]byte(err.Error()))
+			exitCode = -1
+		}
+		done <- exitCode
+	}()
+
+	select {
+	case result.ExitCode = <-done:
+		result.Output = output.String()
+	case <-time.After(config.Timeout):
+		go killHealthcheck(container.ID, pids, done)
+		result.ExitCode = -1
+		result.Output = fmt.Sprintf("Health check exceeded timeout (%s)", config.Timeout)
+	case <-stop:
+		go killHealthcheck(container.ID, pids, done)
+		return nil
+	}
+	result.End = time.Now()
+	return result
+}
+
+// killHealthcheck kills the process of a health check given on pids, which
+// may not be started yet. It returns without killing anything if the check
+// ends first, on done.
+func killHealthcheck(containerID string, pids, done <-chan int) {
+	select {
+	case pid := <-pids:
+		select {
+		case <-done:
+			// the pid may already be reused
+			return
+		default:
+		}
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+			log.Debugf("Error killing health check of %s: %s", containerID, err)
+		}
+	case <-done:
+	}
+}
+
+// limitedBuffer keeps the first maxHealthOutput bytes written to it
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n := maxHealthOutput - b.buf.Len(); n < len(p) {
+		b.buf.Write(p[:n])
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
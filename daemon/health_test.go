@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestKillHealthcheckStartedLate(t *testing.T) {
+	pids := make(chan int, 1)
+	done := make(chan int, 1)
+	killed := make(chan struct{})
+	go func() {
+		killHealthcheck("test", pids, done)
+		close(killed)
+	}()
+
+	// the check process starts after its timeout
+	time.Sleep(50 * time.Millisecond)
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pids <- cmd.Process.Pid
+	select {
+	case <-killed:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("Health check was not killed in 5 seconds")
+	}
+	err := cmd.Wait()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.Sys().(syscall.WaitStatus).Signal() != syscall.SIGKILL {
+		t.Fatalf("Expected the health check to be killed, got %v", err)
+	}
+}
+
+func TestKillHealthcheckEnded(t *testing.T) {
+	pids := make(chan int, 1)
+	done := make(chan int, 1)
+	done <- 0
+	killed := make(chan struct{})
+	go func() {
+		killHealthcheck("test", pids, done)
+		close(killed)
+	}()
+	select {
+	case <-killed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("killHealthcheck did not return after the check ended")
+	}
+}
@@ -112,7 +112,7 @@ func (daemon *Daemon) DeleteImage(eng *engine.Engine, name string, imgs *engine.
 			out := &engine.Env{}
 			out.Set("Untagged", utils.ImageReference(repoName, tag))
 			imgs.Add(out)
-			eng.Job("log", "untag", img.ID, "").Run()
+			graph.LogImageEvent(eng, "untag", img.ID, img, utils.ImageReference(repoName, tag))
 		}
 	}
 	tags = daemon.Repositories().ByID()[img.ID]
@@ -127,7 +127,7 @@ func (daemon *Daemon) DeleteImage(eng *engine.Engine, name string, imgs *engine.
 			out := &engine.Env{}
 			out.SetJson("Deleted", img.ID)
 			imgs.Add(out)
-			eng.Job("log", "delete", img.ID, "").Run()
+			graph.LogImageEvent(eng, "delete", img.ID, img, "")
 			if img.Parent != "" && !noprune {
 				err := daemon.DeleteImage(eng, img.Parent, imgs, false, force, noprune)
 				if first {
@@ -170,13 +170,18 @@ func (daemon *Daemon) canDeleteImage(imgID string, force bool) error {
 	return nil
 }
 
-func (daemon *Daemon) ImageClean(job *engine.Job) engine.Status {
-	isDevmapper := false
-	var driver *devmapper.Driver
-	if daemon.GraphDriver().String() == "devicemapper" {
-		isDevmapper = true
-		driver = reflect.ValueOf(daemon.GraphDriver()).Elem().FieldByName("ProtoDriver").Elem().Interface().(*devmapper.Driver)
+// devmapperDriver returns the devicemapper driver of the daemon, or nil if
+// the daemon uses another storage driver
+func (daemon *Daemon) devmapperDriver() *devmapper.Driver {
+	if daemon.GraphDriver().String() != "devicemapper" {
+		return nil
 	}
+	return reflect.ValueOf(daemon.GraphDriver()).Elem().FieldByName("ProtoDriver").Elem().Interface().(*devmapper.Driver)
+}
+
+func (daemon *Daemon) ImageClean(job *engine.Job) engine.Status {
+	driver := daemon.devmapperDriver()
+	isDevmapper := driver != nil
 	cleanInterval, err := strconv.ParseInt(job.Args[0], 10, 64)
 	if err != nil {
 		return job.Error(err)
@@ -215,4 +220,3 @@ func (daemon *Daemon) ImageClean(job *engine.Job) engine.Status {
 	}
 	return engine.StatusOK
 }
-
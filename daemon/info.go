@@ -73,6 +73,8 @@ func (daemon *Daemon) CmdInfo(job *engine.Job) engine.Status {
 	v.SetJson("DriverStatus", daemon.GraphDriver().Status())
 	v.SetBool("MemoryLimit", daemon.SystemConfig().MemoryLimit)
 	v.SetBool("SwapLimit", daemon.SystemConfig().SwapLimit)
+	v.SetBool("PidsLimit", daemon.SystemConfig().PidsLimit)
+	v.SetBool("Seccomp", daemon.SystemConfig().Seccomp)
 	v.SetBool("IPv4Forwarding", !daemon.SystemConfig().IPv4ForwardingDisabled)
 	v.SetBool("Debug", os.Getenv("DEBUG") != "")
 	v.SetInt("NFd", utils.GetTotalUsedFds())
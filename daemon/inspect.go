@@ -47,6 +47,7 @@ func (daemon *Daemon) ContainerInspect(job *engine.Job) engine.Status {
 	out.Set("LogPath", container.LogPath)
 	out.SetJson("Name", container.Name)
 	out.SetInt("RestartCount", container.RestartCount)
+	out.Set("RestartReason", container.RestartReason)
 	out.Set("Driver", container.Driver)
 	out.Set("ExecDriver", container.ExecDriver)
 	out.Set("MountLabel", container.MountLabel)
@@ -54,6 +55,7 @@ func (daemon *Daemon) ContainerInspect(job *engine.Job) engine.Status {
 	out.SetJson("Volumes", container.Volumes)
 	out.SetJson("VolumesRW", container.VolumesRW)
 	out.SetJson("AppArmorProfile", container.AppArmorProfile)
+	out.Set("SeccompProfile", container.SeccompProfile)
 
 	out.SetList("ExecIDs", container.GetExecIDs())
 
@@ -64,10 +66,10 @@ func (daemon *Daemon) ContainerInspect(job *engine.Job) engine.Status {
 	}
 	// we need this trick to preserve empty log driver, so
 	// container will use daemon defaults even if daemon change them
-	if container.hostConfig.LogConfig.Type == "" {
-		container.hostConfig.LogConfig = daemon.defaultLogConfig
+	if logConfig := container.hostConfig.LogConfig; logConfig.Type == "" {
+		container.hostConfig.LogConfig = daemon.mergeLogConfig(logConfig)
 		defer func() {
-			container.hostConfig.LogConfig = runconfig.LogConfig{}
+			container.hostConfig.LogConfig = logConfig
 		}()
 	}
 
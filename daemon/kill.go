@@ -43,18 +43,28 @@ func (daemon *Daemon) ContainerKill(job *engine.Job) engine.Status {
 		return job.Error(err)
 	}
 
+	// Like docker stop, any signal keeps the restart policy from restarting
+	// the container when it exits
+	container.setManuallyStopped(true)
+
 	// If no signal is passed, or SIGKILL, perform regular Kill (SIGKILL + wait())
 	if sig == 0 || syscall.Signal(sig) == syscall.SIGKILL {
 		if err := container.Kill(); err != nil {
+			container.setManuallyStopped(false)
 			return job.Errorf("Cannot kill container %s: %s", name, err)
 		}
-		container.LogEvent("kill")
+		container.LogEventWithAttributes("kill", map[string]string{
+			"signal": strconv.Itoa(int(syscall.SIGKILL)),
+		})
 	} else {
 		// Otherwise, just send the requested signal
 		if err := container.KillSig(int(sig)); err != nil {
+			container.setManuallyStopped(false)
 			return job.Errorf("Cannot kill container %s: %s", name, err)
 		}
-		// FIXME: Add event for signals
+		container.LogEventWithAttributes("kill", map[string]string{
+			"signal": strconv.FormatUint(sig, 10),
+		})
 	}
 	return engine.StatusOK
 }
@@ -0,0 +1,7 @@
+package daemon
+
+import (
+	// the logging drivers register themselves when imported
+	_ "github.com/docker/docker/daemon/logger/jsonfilelog"
+	_ "github.com/docker/docker/daemon/logger/syslog"
+)
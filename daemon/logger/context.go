@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/docker/docker/pkg/common"
+)
+
+// Context provides enough information for a logging driver to identify the
+// container it logs for. Its exported methods are available in the "tag"
+// log option template, e.g. --log-opt tag="{{.ImageName}}/{{.Name}}/{{.ID}}"
+type Context struct {
+	Config             map[string]string
+	ContainerID        string
+	ContainerName      string
+	ContainerImageID   string
+	ContainerImageName string
+	ContainerCreated   time.Time
+	ContainerLabels    map[string]string
+	ContainerEnv       []string
+	LogPath            string
+}
+
+// ID returns the container ID shortened to 12 characters
+func (ctx *Context) ID() string {
+	return common.TruncateID(ctx.ContainerID)
+}
+
+// FullID is an alias of ContainerID
+func (ctx *Context) FullID() string {
+	return ctx.ContainerID
+}
+
+// Name returns the container name without leading slash
+func (ctx *Context) Name() string {
+	return strings.TrimPrefix(ctx.ContainerName, "/")
+}
+
+// ImageID returns the image ID shortened to 12 characters
+func (ctx *Context) ImageID() string {
+	return common.TruncateID(ctx.ContainerImageID)
+}
+
+// ImageFullID is an alias of ContainerImageID
+func (ctx *Context) ImageFullID() string {
+	return ctx.ContainerImageID
+}
+
+// ImageName is an alias of ContainerImageName
+func (ctx *Context) ImageName() string {
+	return ctx.ContainerImageName
+}
+
+// Labels is an alias of ContainerLabels
+func (ctx *Context) Labels() map[string]string {
+	return ctx.ContainerLabels
+}
+
+// Tag renders the "tag" log option template. defaultTemplate is used if the
+// option is not set.
+func (ctx *Context) Tag(defaultTemplate string) (string, error) {
+	tmpl := ctx.Config["tag"]
+	if tmpl == "" {
+		tmpl = defaultTemplate
+	}
+	t, err := template.New("tag").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("Invalid log tag template %q: %s", tmpl, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, ctx); err != nil {
+		return "", fmt.Errorf("Failed to execute log tag template %q: %s", tmpl, err)
+	}
+	return buf.String(), nil
+}
+
+// ExtraAttributes returns the container labels and environment variables
+// selected by the comma separated "labels" and "env" log options.
+func (ctx *Context) ExtraAttributes() map[string]string {
+	extra := make(map[string]string)
+	if labels, ok := ctx.Config["labels"]; ok && labels != "" {
+		for _, l := range strings.Split(labels, ",") {
+			if v, ok := ctx.ContainerLabels[l]; ok {
+				extra[l] = v
+			}
+		}
+	}
+	if env, ok := ctx.Config["env"]; ok && env != "" {
+		envMapping := make(map[string]string)
+		for _, kv := range ctx.ContainerEnv {
+			if parts := strings.SplitN(kv, "=", 2); len(parts) == 2 {
+				envMapping[parts[0]] = parts[1]
+			}
+		}
+		for _, e := range strings.Split(env, ",") {
+			if v, ok := envMapping[e]; ok {
+				extra[e] = v
+			}
+		}
+	}
+	return extra
+}
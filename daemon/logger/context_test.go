@@ -0,0 +1,34 @@
+package logger
+
+import "testing"
+
+func TestContextTag(t *testing.T) {
+	ctx := &Context{
+		Config:             map[string]string{},
+		ContainerID:        "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+		ContainerName:      "/web1",
+		ContainerImageID:   "5e1d2ab1f2e0a3b3cd7ad1a2c7f1e1d8a2bbf3d1ad1a9b7e2b7e0b2d2c3e4f5a",
+		ContainerImageName: "nginx:latest",
+	}
+	tag, err := ctx.Tag("{{.ID}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "a7317399f3f8" {
+		t.Fatalf("Wrong default tag: %q", tag)
+	}
+
+	ctx.Config["tag"] = "{{.ImageName}}/{{.Name}}/{{.ImageID}}"
+	tag, err = ctx.Tag("{{.ID}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "nginx:latest/web1/5e1d2ab1f2e0" {
+		t.Fatalf("Wrong tag: %q", tag)
+	}
+
+	ctx.Config["tag"] = "{{.Unknown}}"
+	if _, err := ctx.Tag("{{.ID}}"); err == nil {
+		t.Fatal("Expected error for unknown template field")
+	}
+}
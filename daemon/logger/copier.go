@@ -19,14 +19,17 @@ type Copier struct {
 	srcs     map[string]io.Reader
 	dst      Logger
 	copyJobs sync.WaitGroup
+	// multiline is nil if every line is a separate message
+	multiline *MultilineConfig
 }
 
-// NewCopier creates new Copier
-func NewCopier(cid string, srcs map[string]io.Reader, dst Logger) (*Copier, error) {
+// NewCopier creates new Copier, multiline can be nil
+func NewCopier(cid string, srcs map[string]io.Reader, dst Logger, multiline *MultilineConfig) (*Copier, error) {
 	return &Copier{
-		cid:  cid,
-		srcs: srcs,
-		dst:  dst,
+		cid:       cid,
+		srcs:      srcs,
+		dst:       dst,
+		multiline: multiline,
 	}, nil
 }
 
@@ -40,6 +43,10 @@ func (c *Copier) Run() {
 
 func (c *Copier) copySrc(name string, src io.Reader) {
 	defer c.copyJobs.Done()
+	if c.multiline != nil {
+		c.copyMultiline(name, src)
+		return
+	}
 	scanner := bufio.NewScanner(src)
 	for scanner.Scan() {
 		if err := c.dst.Log(&Message{ContainerID: c.cid, Line: scanner.Bytes(), Source: name, Timestamp: time.Now().UTC()}); err != nil {
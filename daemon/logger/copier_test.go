@@ -65,7 +65,8 @@ func TestCopier(t *testing.T) {
 			"stdout": &stdout,
 			"stderr": &stderr,
 		},
-		jsonLog)
+		jsonLog,
+		nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,28 @@
+package logger
+
+import "fmt"
+
+// Creator creates a logging driver for the container of ctx
+type Creator func(ctx Context) (Logger, error)
+
+// drivers are the registered logging drivers, by name
+var drivers = make(map[string]Creator)
+
+// RegisterLogDriver registers a logging driver. It is called by the init
+// function of the package of the driver.
+func RegisterLogDriver(name string, c Creator) error {
+	if _, exists := drivers[name]; exists {
+		return fmt.Errorf("Logging driver %s is already registered", name)
+	}
+	drivers[name] = c
+	return nil
+}
+
+// GetLogDriver returns the creator of the logging driver registered as name
+func GetLogDriver(name string) (Creator, error) {
+	c, exists := drivers[name]
+	if !exists {
+		return nil, fmt.Errorf("Unknown logging driver: %s", name)
+	}
+	return c, nil
+}
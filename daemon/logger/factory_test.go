@@ -0,0 +1,20 @@
+package logger
+
+import "testing"
+
+func TestRegisterLogDriver(t *testing.T) {
+	create := func(ctx Context) (Logger, error) { return nil, nil }
+	if err := RegisterLogDriver("test-factory", create); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(drivers, "test-factory")
+	if err := RegisterLogDriver("test-factory", create); err == nil {
+		t.Fatal("Expected an error registering a logging driver twice")
+	}
+	if c, err := GetLogDriver("test-factory"); err != nil || c == nil {
+		t.Fatalf("Expected the registered logging driver, got %v", err)
+	}
+	if _, err := GetLogDriver("unknown"); err == nil {
+		t.Fatal("Expected an error for an unknown logging driver")
+	}
+}
@@ -9,23 +9,43 @@ import (
 	"github.com/docker/docker/pkg/jsonlog"
 )
 
+// Name is the name of the logging driver
+const Name = "json-file"
+
+func init() {
+	if err := logger.RegisterLogDriver(Name, New); err != nil {
+		panic(err)
+	}
+}
+
 // JSONFileLogger is Logger implementation for default docker logging:
 // JSON objects to file
 type JSONFileLogger struct {
-	buf *bytes.Buffer
-	f   *os.File   // store for closing
-	mu  sync.Mutex // protects buffer
+	buf   *bytes.Buffer
+	f     *os.File          // store for closing
+	mu    sync.Mutex        // protects buffer
+	extra map[string]string // added to every record as attrs
 }
 
-// New creates new JSONFileLogger which writes to filename
-func New(filename string) (logger.Logger, error) {
-	log, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+// New creates new JSONFileLogger which writes to ctx.LogPath. The "tag",
+// "labels" and "env" log options are stored as attrs of every record.
+func New(ctx logger.Context) (logger.Logger, error) {
+	extra := ctx.ExtraAttributes()
+	if _, ok := ctx.Config["tag"]; ok {
+		tag, err := ctx.Tag("")
+		if err != nil {
+			return nil, err
+		}
+		extra["tag"] = tag
+	}
+	log, err := os.OpenFile(ctx.LogPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
 	}
 	return &JSONFileLogger{
-		f:   log,
-		buf: bytes.NewBuffer(nil),
+		f:     log,
+		buf:   bytes.NewBuffer(nil),
+		extra: extra,
 	}, nil
 }
 
@@ -33,7 +53,7 @@ func New(filename string) (logger.Logger, error) {
 func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	err := (&jsonlog.JSONLog{Log: string(msg.Line) + "\n", Stream: msg.Source, Created: msg.Timestamp}).MarshalJSONBuf(l.buf)
+	err := (&jsonlog.JSONLog{Log: string(msg.Line) + "\n", Stream: msg.Source, Attrs: l.extra, Created: msg.Timestamp}).MarshalJSONBuf(l.buf)
 	if err != nil {
 		return err
 	}
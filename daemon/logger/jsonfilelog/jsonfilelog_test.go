@@ -18,7 +18,7 @@ func TestJSONFileLogger(t *testing.T) {
 	}
 	defer os.RemoveAll(tmp)
 	filename := filepath.Join(tmp, "container.log")
-	l, err := New(filename)
+	l, err := New(logger.Context{LogPath: filename})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -54,7 +54,7 @@ func BenchmarkJSONFileLogger(b *testing.B) {
 	}
 	defer os.RemoveAll(tmp)
 	filename := filepath.Join(tmp, "container.log")
-	l, err := New(filename)
+	l, err := New(logger.Context{LogPath: filename})
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -76,3 +76,41 @@ func BenchmarkJSONFileLogger(b *testing.B) {
 		}
 	}
 }
+
+func TestJSONFileLoggerWithOpts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	ctx := logger.Context{
+		Config: map[string]string{
+			"tag":    "{{.ImageName}}/{{.Name}}",
+			"labels": "team,missing",
+			"env":    "APP_ENV",
+		},
+		ContainerName:      "/web1",
+		ContainerImageName: "busybox",
+		ContainerLabels:    map[string]string{"team": "payments", "other": "x"},
+		ContainerEnv:       []string{"APP_ENV=prod", "SECRET=y"},
+		LogPath:            filename,
+	}
+	l, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Log(&logger.Message{Line: []byte("line1"), Source: "src1"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"log":"line1\n","stream":"src1","attrs":{"APP_ENV":"prod","tag":"busybox/web1","team":"payments"},"time":"0001-01-01T00:00:00Z"}
+`
+	if string(res) != expected {
+		t.Fatalf("Wrong log content: %q, expected %q", res, expected)
+	}
+}
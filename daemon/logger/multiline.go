@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/units"
+)
+
+const (
+	defaultMultilineFlushTimeout = time.Second
+	defaultMultilineMaxSize      = 64 * 1024
+)
+
+// MultilineConfig describes how continuation lines are joined into a single
+// Message before it is passed to the logging driver.
+type MultilineConfig struct {
+	// StartPattern matches lines which begin a new message, all other lines
+	// are treated as continuation of the previous one
+	StartPattern *regexp.Regexp
+	// Indent treats lines starting with a space or a tab as continuation
+	Indent bool
+	// FlushTimeout is how long a pending message waits for more lines
+	FlushTimeout time.Duration
+	// MaxSize is maximum size of joined message in bytes
+	MaxSize int
+}
+
+// ParseMultilineConfig creates MultilineConfig from the log options of
+// container. It returns nil if multiline mode is not requested.
+// Recognized options are:
+//   multiline-pattern=<regexp>       lines matching regexp start a new message
+//   multiline-indent=true            indented lines continue previous message
+//   multiline-flush-timeout=<dur>    flush pending message after this delay (default 1s)
+//   multiline-max-size=<size>        maximum size of joined message (default 64k)
+func ParseMultilineConfig(opts map[string]string) (*MultilineConfig, error) {
+	cfg := &MultilineConfig{
+		FlushTimeout: defaultMultilineFlushTimeout,
+		MaxSize:      defaultMultilineMaxSize,
+	}
+	if v, ok := opts["multiline-pattern"]; ok && v != "" {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid multiline-pattern %q: %s", v, err)
+		}
+		cfg.StartPattern = re
+	}
+	if v, ok := opts["multiline-indent"]; ok {
+		indent, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid multiline-indent %q: %s", v, err)
+		}
+		cfg.Indent = indent
+	}
+	if cfg.StartPattern == nil && !cfg.Indent {
+		return nil, nil
+	}
+	if v, ok := opts["multiline-flush-timeout"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("Invalid multiline-flush-timeout %q", v)
+		}
+		cfg.FlushTimeout = d
+	}
+	if v, ok := opts["multiline-max-size"]; ok {
+		size, err := units.RAMInBytes(v)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("Invalid multiline-max-size %q", v)
+		}
+		cfg.MaxSize = int(size)
+	}
+	return cfg, nil
+}
+
+// isContinuation reports whether line must be appended to the pending message
+func (cfg *MultilineConfig) isContinuation(line []byte) bool {
+	if cfg.Indent && len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+		return true
+	}
+	return cfg.StartPattern != nil && !cfg.StartPattern.Match(line)
+}
+
+// copyMultiline reads lines from src and joins continuation lines before
+// sending them to destination logger. Pending message is flushed when a new
+// message starts, when it would grow over MaxSize, after FlushTimeout without
+// new lines and when src is exhausted.
+func (c *Copier) copyMultiline(name string, src io.Reader) {
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(src)
+		for scanner.Scan() {
+			// scanner reuses its buffer, so line must be copied
+			line := make([]byte, len(scanner.Bytes()))
+			copy(line, scanner.Bytes())
+			lines <- line
+		}
+		if err := scanner.Err(); err != nil {
+			logrus.Errorf("Error scanning log stream: %s", err)
+		}
+	}()
+
+	var (
+		buf     bytes.Buffer
+		ts      time.Time
+		timeout <-chan time.Time
+	)
+	flush := func() {
+		timeout = nil
+		if buf.Len() == 0 {
+			return
+		}
+		line := make([]byte, buf.Len())
+		copy(line, buf.Bytes())
+		buf.Reset()
+		if err := c.dst.Log(&Message{ContainerID: c.cid, Line: line, Source: name, Timestamp: ts}); err != nil {
+			logrus.Errorf("Failed to log msg %q for logger %s: %s", line, c.dst.Name(), err)
+		}
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+			if buf.Len() > 0 && (!c.multiline.isContinuation(line) || buf.Len()+1+len(line) > c.multiline.MaxSize) {
+				flush()
+			}
+			if buf.Len() == 0 {
+				ts = time.Now().UTC()
+			} else {
+				buf.WriteByte('\n')
+			}
+			buf.Write(line)
+			timeout = time.After(c.multiline.FlushTimeout)
+		case <-timeout:
+			flush()
+		}
+	}
+}
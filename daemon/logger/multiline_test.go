@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMultilineConfig(t *testing.T) {
+	cfg, err := ParseMultilineConfig(map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg != nil {
+		t.Fatalf("Expected nil config without multiline options, got %v", cfg)
+	}
+
+	cfg, err = ParseMultilineConfig(map[string]string{
+		"multiline-pattern":       `^\d{4}-`,
+		"multiline-flush-timeout": "200ms",
+		"multiline-max-size":      "1k",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.StartPattern == nil || cfg.Indent {
+		t.Fatalf("Wrong config: %v", cfg)
+	}
+	if cfg.FlushTimeout != 200*time.Millisecond {
+		t.Fatalf("Wrong FlushTimeout: %s", cfg.FlushTimeout)
+	}
+	if cfg.MaxSize != 1024 {
+		t.Fatalf("Wrong MaxSize: %d", cfg.MaxSize)
+	}
+
+	for _, opts := range []map[string]string{
+		{"multiline-pattern": "("},
+		{"multiline-indent": "maybe"},
+		{"multiline-indent": "true", "multiline-flush-timeout": "-1s"},
+		{"multiline-indent": "true", "multiline-max-size": "huge"},
+	} {
+		if _, err := ParseMultilineConfig(opts); err == nil {
+			t.Fatalf("Expected error for %v", opts)
+		}
+	}
+}
+
+func runMultilineCopier(t *testing.T, input string, cfg *MultilineConfig) []string {
+	var buf bytes.Buffer
+	c, err := NewCopier("cid", map[string]io.Reader{"stdout": strings.NewReader(input)}, &TestLoggerText{Buffer: &buf}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Run()
+	wait := make(chan struct{})
+	go func() {
+		c.Wait()
+		close(wait)
+	}()
+	select {
+	case <-time.After(1 * time.Second):
+		t.Fatal("Copier failed to do its work in 1 second")
+	case <-wait:
+	}
+	// TestLoggerText writes "cid stdout <line>\n" for every message
+	var msgs []string
+	for _, rec := range strings.SplitAfter(buf.String(), "\n") {
+		if strings.HasPrefix(rec, "cid stdout ") {
+			msgs = append(msgs, strings.TrimPrefix(rec, "cid stdout "))
+		} else if rec != "" {
+			msgs[len(msgs)-1] += rec
+		}
+	}
+	for i := range msgs {
+		msgs[i] = strings.TrimSuffix(msgs[i], "\n")
+	}
+	return msgs
+}
+
+func TestCopierMultilineIndent(t *testing.T) {
+	input := "Exception in thread \"main\" java.lang.NullPointerException\n" +
+		"\tat Foo.bar(Foo.java:10)\n" +
+		"\tat Foo.main(Foo.java:5)\n" +
+		"next message\n"
+	cfg := &MultilineConfig{Indent: true, FlushTimeout: time.Second, MaxSize: 1024}
+	msgs := runMultilineCopier(t, input, cfg)
+	if len(msgs) != 2 {
+		t.Fatalf("Expected 2 messages, got %d: %q", len(msgs), msgs)
+	}
+	if msgs[0] != "Exception in thread \"main\" java.lang.NullPointerException\n\tat Foo.bar(Foo.java:10)\n\tat Foo.main(Foo.java:5)" {
+		t.Fatalf("Wrong joined message: %q", msgs[0])
+	}
+	if msgs[1] != "next message" {
+		t.Fatalf("Wrong second message: %q", msgs[1])
+	}
+}
+
+func TestCopierMultilineMaxSize(t *testing.T) {
+	input := "start\naaaa\nbbbb\ncccc\n"
+	cfg, err := ParseMultilineConfig(map[string]string{
+		"multiline-pattern":  "^start",
+		"multiline-max-size": "12",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := runMultilineCopier(t, input, cfg)
+	if len(msgs) != 2 || msgs[0] != "start\naaaa" || msgs[1] != "bbbb\ncccc" {
+		t.Fatalf("Wrong messages: %q", msgs)
+	}
+}
+
+type testLoggerChan chan *Message
+
+func (l testLoggerChan) Log(m *Message) error {
+	l <- m
+	return nil
+}
+
+func (l testLoggerChan) Close() error {
+	return nil
+}
+
+func (l testLoggerChan) Name() string {
+	return "chan"
+}
+
+func TestCopierMultilineFlushTimeout(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	l := make(testLoggerChan, 1)
+	cfg := &MultilineConfig{Indent: true, FlushTimeout: 50 * time.Millisecond, MaxSize: 1024}
+	c, err := NewCopier("cid", map[string]io.Reader{"stdout": r}, l, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Run()
+	if _, err := w.Write([]byte("first\n  continued\n")); err != nil {
+		t.Fatal(err)
+	}
+	// message must be flushed while the stream is still open
+	select {
+	case <-time.After(1 * time.Second):
+		t.Fatal("Pending message was not flushed after timeout")
+	case msg := <-l:
+		if string(msg.Line) != "first\n  continued" {
+			t.Fatalf("Wrong message: %q", msg.Line)
+		}
+	}
+}
@@ -10,19 +10,35 @@ import (
 	"github.com/docker/docker/daemon/logger"
 )
 
+// Name is the name of the logging driver
+const Name = "syslog"
+
+func init() {
+	if err := logger.RegisterLogDriver(Name, New); err != nil {
+		panic(err)
+	}
+}
+
 type Syslog struct {
 	writer *syslog.Writer
 	tag    string
 	mu     sync.Mutex
 }
 
-func New(tag string) (logger.Logger, error) {
+// New creates new Syslog logger. Messages are tagged with the "tag" log
+// option template, which defaults to the short container ID.
+func New(ctx logger.Context) (logger.Logger, error) {
+	tag, err := ctx.Tag("{{.ID}}")
+	if err != nil {
+		return nil, err
+	}
 	log, err := syslog.New(syslog.LOG_DAEMON, fmt.Sprintf("%s/%s", path.Base(os.Args[0]), tag))
 	if err != nil {
 		return nil, err
 	}
 	return &Syslog{
 		writer: log,
+		tag:    tag,
 	}, nil
 }
 
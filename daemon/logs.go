@@ -80,16 +80,10 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) engine.Status {
 		}
 		if lines != 0 {
 			if lines > 0 {
-				f := cLog.(*os.File)
-				ls, err := tailfile.TailFile(f, lines)
+				cLog, err = tailLog(cLog, lines)
 				if err != nil {
 					return job.Error(err)
 				}
-				tmp := bytes.NewBuffer([]byte{})
-				for _, l := range ls {
-					fmt.Fprintf(tmp, "%s\n", l)
-				}
-				cLog = tmp
 			}
 			dec := json.NewDecoder(cLog)
 			l := &jsonlog.JSONLog{}
@@ -150,3 +144,17 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) engine.Status {
 	}
 	return engine.StatusOK
 }
+
+// tailLog returns reader of last n lines of json log file cLog
+func tailLog(cLog io.Reader, n int) (io.Reader, error) {
+	f := cLog.(*os.File)
+	ls, err := tailfile.TailFile(f, n)
+	if err != nil {
+		return nil, err
+	}
+	tmp := bytes.NewBuffer([]byte{})
+	for _, l := range ls {
+		fmt.Fprintf(tmp, "%s\n", l)
+	}
+	return tmp, nil
+}
@@ -0,0 +1,305 @@
+package daemon
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/jsonlog"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+// followReorderWindow is how long followed lines are held back so that lines
+// from different containers can be sent in timestamp order
+const followReorderWindow = 100 * time.Millisecond
+
+// logRecordHeap is a min-heap of log records ordered by time. Records of the
+// same container keep their relative order.
+type logRecordHeap []*logRecordItem
+
+type logRecordItem struct {
+	record types.ContainerLogRecord
+	seq    int64
+	// src is index of the decoder the record was read from, -1 for followed
+	// records
+	src int
+}
+
+func (h logRecordHeap) Len() int { return len(h) }
+func (h logRecordHeap) Less(i, j int) bool {
+	if h[i].record.Time.Equal(h[j].record.Time) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].record.Time.Before(h[j].record.Time)
+}
+func (h logRecordHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *logRecordHeap) Push(x interface{}) { *h = append(*h, x.(*logRecordItem)) }
+func (h *logRecordHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// logSource decodes json log records of a single container
+type logSource struct {
+	container *Container
+	dec       *json.Decoder
+}
+
+func (s *logSource) next(stdout, stderr bool) (*types.ContainerLogRecord, error) {
+	l := &jsonlog.JSONLog{}
+	for {
+		if err := s.dec.Decode(l); err != nil {
+			return nil, err
+		}
+		if (l.Stream == "stdout" && stdout) || (l.Stream == "stderr" && stderr) {
+			return &types.ContainerLogRecord{
+				ID:     s.container.ID,
+				Name:   strings.TrimPrefix(s.container.Name, "/"),
+				Stream: l.Stream,
+				Log:    l.Log,
+				Time:   l.Created,
+			}, nil
+		}
+		l.Reset()
+	}
+}
+
+// resolveLogsContainers returns the containers named in job arguments
+// followed by the containers matching the "label" filter
+func (daemon *Daemon) resolveLogsContainers(names []string, logFilters filters.Args) ([]*Container, error) {
+	var (
+		containers []*Container
+		seen       = make(map[string]bool)
+	)
+	for _, name := range names {
+		container, err := daemon.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[container.ID] {
+			seen[container.ID] = true
+			containers = append(containers, container)
+		}
+	}
+	if _, ok := logFilters["label"]; ok {
+		for _, container := range daemon.List() {
+			if seen[container.ID] || !logFilters.MatchKVList("label", container.Config.Labels) {
+				continue
+			}
+			seen[container.ID] = true
+			containers = append(containers, container)
+		}
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("No containers match the given names and filters")
+	}
+	for _, container := range containers {
+		if container.LogDriverType() != "json-file" {
+			return nil, fmt.Errorf("\"logs\" endpoint is supported only for \"json-file\" logging driver, container %s uses %q", container.Name, container.LogDriverType())
+		}
+	}
+	return containers, nil
+}
+
+// ContainersLogs merges the logs of several containers into a single stream
+// of json encoded types.ContainerLogRecord ordered by timestamp. Containers
+// are given as job arguments and/or with a "label" filter. The stream starts
+// with a record without stream and log for each of the containers, and ends
+// when the job is cancelled while following the logs.
+func (daemon *Daemon) ContainersLogs(job *engine.Job) engine.Status {
+	var (
+		stdout = job.GetenvBool("stdout")
+		stderr = job.GetenvBool("stderr")
+		tail   = job.Getenv("tail")
+		follow = job.GetenvBool("follow")
+		lines  = -1
+	)
+	if !(stdout || stderr) {
+		return job.Errorf("You must choose at least one stream")
+	}
+	logFilters, err := filters.FromParam(job.Getenv("filters"))
+	if err != nil {
+		return job.Error(err)
+	}
+	if len(job.Args) == 0 && len(logFilters["label"]) == 0 {
+		return job.Errorf("Usage: %s CONTAINER [CONTAINER...] or a label filter", job.Name)
+	}
+	containers, err := daemon.resolveLogsContainers(job.Args, logFilters)
+	if err != nil {
+		return job.Error(err)
+	}
+	if tail != "" && tail != "all" {
+		lines, err = strconv.Atoi(tail)
+		if err != nil {
+			log.Errorf("Failed to parse tail %s, error: %v, show all logs", tail, err)
+			lines = -1
+		}
+	}
+
+	enc := json.NewEncoder(job.Stdout)
+	for _, container := range containers {
+		if err := enc.Encode(&types.ContainerLogRecord{ID: container.ID, Name: strings.TrimPrefix(container.Name, "/")}); err != nil {
+			return job.Error(err)
+		}
+	}
+	if lines != 0 {
+		if err := mergeLogFiles(containers, lines, stdout, stderr, enc); err != nil {
+			return job.Error(err)
+		}
+	}
+	if follow {
+		if err := followLogs(containers, stdout, stderr, enc, job.WaitCancelled()); err != nil {
+			log.Debugf("Stopped following logs: %s", err)
+		}
+	}
+	return engine.StatusOK
+}
+
+// mergeLogFiles writes records of the json log files of containers to enc in
+// timestamp order. If lines is positive only the last lines of each file are
+// used.
+func mergeLogFiles(containers []*Container, lines int, stdout, stderr bool, enc *json.Encoder) error {
+	var (
+		sources []*logSource
+		h       = &logRecordHeap{}
+	)
+	for _, container := range containers {
+		cLog, err := container.ReadLog("json")
+		if err != nil {
+			log.Errorf("Error reading logs (json) of %s: %s", container.ID, err)
+			continue
+		}
+		if c, ok := cLog.(io.Closer); ok {
+			defer c.Close()
+		}
+		if lines > 0 {
+			if cLog, err = tailLog(cLog, lines); err != nil {
+				return err
+			}
+		}
+		sources = append(sources, &logSource{container: container, dec: json.NewDecoder(cLog)})
+	}
+	// read one record per source, then always replace the popped record by
+	// the next one of the same source
+	var seq int64
+	pushNext := func(i int) {
+		r, err := sources[i].next(stdout, stderr)
+		if err != nil {
+			if err != io.EOF {
+				log.Errorf("Error streaming logs of %s: %s", sources[i].container.ID, err)
+			}
+			return
+		}
+		seq++
+		heap.Push(h, &logRecordItem{record: *r, seq: seq, src: i})
+	}
+	for i := range sources {
+		pushNext(i)
+	}
+	for h.Len() > 0 {
+		item := heap.Pop(h).(*logRecordItem)
+		if err := enc.Encode(&item.record); err != nil {
+			return err
+		}
+		pushNext(item.src)
+	}
+	return nil
+}
+
+// followLogs streams new records of running containers to enc until all of
+// them stop, writing to enc fails or cancelled is closed. Records are held
+// back for followReorderWindow to send them in timestamp order.
+func followLogs(containers []*Container, stdout, stderr bool, enc *json.Encoder, cancelled <-chan struct{}) error {
+	var (
+		pipes   []io.ReadCloser
+		records = make(chan *types.ContainerLogRecord)
+		done    = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	defer func() {
+		close(done)
+		for _, p := range pipes {
+			p.Close()
+		}
+	}()
+	for _, container := range containers {
+		if !container.IsRunning() {
+			continue
+		}
+		var cPipes []io.ReadCloser
+		if stdout {
+			cPipes = append(cPipes, container.StdoutLogPipe())
+		}
+		if stderr {
+			cPipes = append(cPipes, container.StderrLogPipe())
+		}
+		for _, p := range cPipes {
+			pipes = append(pipes, p)
+			wg.Add(1)
+			go func(container *Container, p io.Reader) {
+				defer wg.Done()
+				src := &logSource{container: container, dec: json.NewDecoder(p)}
+				for {
+					r, err := src.next(true, true)
+					if err != nil {
+						return
+					}
+					select {
+					case records <- r:
+					case <-done:
+						return
+					}
+				}
+			}(container, p)
+		}
+	}
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	var (
+		h      = &logRecordHeap{}
+		seq    int64
+		ticker = time.NewTicker(followReorderWindow / 2)
+	)
+	defer ticker.Stop()
+	// flush sends held back records logged before until, or all of them if
+	// until is zero
+	flush := func(until time.Time) error {
+		for h.Len() > 0 && (until.IsZero() || (*h)[0].record.Time.Before(until)) {
+			item := heap.Pop(h).(*logRecordItem)
+			if err := enc.Encode(&item.record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for {
+		select {
+		case r := <-records:
+			seq++
+			heap.Push(h, &logRecordItem{record: *r, seq: seq, src: -1})
+		case <-ticker.C:
+			if err := flush(time.Now().UTC().Add(-followReorderWindow)); err != nil {
+				return err
+			}
+		case <-finished:
+			return flush(time.Time{})
+		case <-cancelled:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/broadcastwriter"
+	"github.com/docker/docker/pkg/jsonlog"
+)
+
+func newLogTestContainer(t *testing.T, root, id, name string, logs []jsonlog.JSONLog) *Container {
+	dir := filepath.Join(root, id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	for _, l := range logs {
+		if err := l.MarshalJSONBuf(&buf); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteByte('\n')
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, id+"-json.log"), buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return &Container{ID: id, Name: name, root: dir}
+}
+
+func TestMergeLogFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-logs-merge-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	start := time.Date(2015, 4, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+	web1 := newLogTestContainer(t, root, "aaaa", "/web1", []jsonlog.JSONLog{
+		{Log: "a1\n", Stream: "stdout", Created: at(1)},
+		{Log: "a3\n", Stream: "stderr", Created: at(3)},
+		{Log: "a4\n", Stream: "stdout", Created: at(4)},
+	})
+	web2 := newLogTestContainer(t, root, "bbbb", "/web2", []jsonlog.JSONLog{
+		{Log: "b2\n", Stream: "stdout", Created: at(2)},
+		{Log: "b5\n", Stream: "stdout", Created: at(5)},
+	})
+
+	read := func(lines int, stdout, stderr bool) []types.ContainerLogRecord {
+		var buf bytes.Buffer
+		if err := mergeLogFiles([]*Container{web1, web2}, lines, stdout, stderr, json.NewEncoder(&buf)); err != nil {
+			t.Fatal(err)
+		}
+		var recs []types.ContainerLogRecord
+		dec := json.NewDecoder(&buf)
+		for {
+			var r types.ContainerLogRecord
+			if err := dec.Decode(&r); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			recs = append(recs, r)
+		}
+		return recs
+	}
+
+	recs := read(-1, true, true)
+	expected := []string{"web1 a1\n", "web2 b2\n", "web1 a3\n", "web1 a4\n", "web2 b5\n"}
+	if len(recs) != len(expected) {
+		t.Fatalf("Expected %d records, got %d: %v", len(expected), len(recs), recs)
+	}
+	for i, r := range recs {
+		if r.Name+" "+r.Log != expected[i] {
+			t.Fatalf("Record %d: expected %q, got %q", i, expected[i], r.Name+" "+r.Log)
+		}
+	}
+
+	recs = read(1, true, false)
+	if len(recs) != 2 || recs[0].Log != "a4\n" || recs[1].Log != "b5\n" {
+		t.Fatalf("Wrong tail of stdout: %v", recs)
+	}
+}
+
+func TestFollowLogsCancelled(t *testing.T) {
+	container := &Container{ID: "aaaa", Name: "/web1", State: NewState()}
+	container.stdout = broadcastwriter.New()
+	container.stderr = broadcastwriter.New()
+	container.SetRunning(1)
+
+	// the container logs nothing, cancelling stops following it anyway
+	var buf bytes.Buffer
+	cancelled := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- followLogs([]*Container{container}, true, true, json.NewEncoder(&buf), cancelled)
+	}()
+	close(cancelled)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Following the logs of a quiet container didn't stop when cancelled")
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no records, got %s", buf.String())
+	}
+}
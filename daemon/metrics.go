@@ -0,0 +1,255 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/networkdriver/ipallocator"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/metrics"
+)
+
+// Metrics writes container and daemon metrics in the Prometheus text format
+func (daemon *Daemon) Metrics(job *engine.Job) engine.Status {
+	w := metrics.NewWriter(job.Stdout)
+	daemon.writeContainerStates(w)
+	daemon.writeContainerMetrics(w)
+	daemon.writeImageMetrics(w)
+	daemon.writeStorageMetrics(w)
+	writeFixedIPMetrics(w)
+	if err := w.Err(); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// containerMetric is a metric of the resource stats of a container
+type containerMetric struct {
+	name, typ, help string
+	values          func(stats *types.Stats, labels metrics.Labels) []containerSample
+}
+
+type containerSample struct {
+	labels metrics.Labels
+	value  float64
+}
+
+// single returns the samples of a metric with a single value per container
+func single(value func(stats *types.Stats) float64) func(*types.Stats, metrics.Labels) []containerSample {
+	return func(stats *types.Stats, labels metrics.Labels) []containerSample {
+		return []containerSample{{labels, value(stats)}}
+	}
+}
+
+// blkio returns the samples of a blkio metric, one per device and operation
+func blkio(stat string) func(*types.Stats, metrics.Labels) []containerSample {
+	return func(stats *types.Stats, labels metrics.Labels) []containerSample {
+		entries := stats.BlkioStats.IoServiceBytesRecursive
+		if stat == "serviced" {
+			entries = stats.BlkioStats.IoServicedRecursive
+		}
+		var samples []containerSample
+		for _, e := range entries {
+			if e.Op == "Total" {
+				continue
+			}
+			l := metrics.Labels{"device": fmt.Sprintf("%d:%d", e.Major, e.Minor), "op": strings.ToLower(e.Op)}
+			for k, v := range labels {
+				l[k] = v
+			}
+			samples = append(samples, containerSample{l, float64(e.Value)})
+		}
+		return samples
+	}
+}
+
+var containerMetrics = []containerMetric{
+	{"docker_container_cpu_usage_seconds_total", metrics.TypeCounter, "Total CPU time consumed by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.CpuStats.CpuUsage.TotalUsage) / 1e9
+		})},
+	{"docker_container_cpu_throttled_periods_total", metrics.TypeCounter, "Number of CPU periods the container was throttled.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.CpuStats.ThrottlingData.ThrottledPeriods)
+		})},
+	{"docker_container_cpu_throttled_seconds_total", metrics.TypeCounter, "Total time the container was throttled for.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.CpuStats.ThrottlingData.ThrottledTime) / 1e9
+		})},
+	{"docker_container_memory_usage_bytes", metrics.TypeGauge, "Memory used by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.Usage)
+		})},
+	{"docker_container_memory_max_usage_bytes", metrics.TypeGauge, "Maximum memory used by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.MaxUsage)
+		})},
+	{"docker_container_memory_limit_bytes", metrics.TypeGauge, "Memory limit of the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.Limit)
+		})},
+	{"docker_container_memory_failures_total", metrics.TypeCounter, "Number of times the container hit its memory limit.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.Failcnt)
+		})},
+	{"docker_container_memory_rss_bytes", metrics.TypeGauge, "Anonymous memory and swap cache of the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.Rss)
+		})},
+	{"docker_container_memory_cache_bytes", metrics.TypeGauge, "Page cache memory of the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.Cache)
+		})},
+	{"docker_container_memory_swap_bytes", metrics.TypeGauge, "Swap used by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.Swap)
+		})},
+	{"docker_container_memory_mapped_file_bytes", metrics.TypeGauge, "Page cache memory mapped into the processes of the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.MappedFile)
+		})},
+	{"docker_container_oom_kills_total", metrics.TypeCounter, "Number of processes of the container killed by the OOM killer.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.MemoryStats.OomKills)
+		})},
+	{"docker_container_pids", metrics.TypeGauge, "Number of processes in the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.PidsStats.Current)
+		})},
+	{"docker_container_network_receive_bytes_total", metrics.TypeCounter, "Bytes received by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.RxBytes)
+		})},
+	{"docker_container_network_transmit_bytes_total", metrics.TypeCounter, "Bytes sent by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.TxBytes)
+		})},
+	{"docker_container_network_receive_packets_total", metrics.TypeCounter, "Packets received by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.RxPackets)
+		})},
+	{"docker_container_network_transmit_packets_total", metrics.TypeCounter, "Packets sent by the container.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.TxPackets)
+		})},
+	{"docker_container_network_receive_errors_total", metrics.TypeCounter, "Errors receiving packets.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.RxErrors)
+		})},
+	{"docker_container_network_transmit_errors_total", metrics.TypeCounter, "Errors sending packets.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.TxErrors)
+		})},
+	{"docker_container_network_receive_dropped_total", metrics.TypeCounter, "Received packets which were dropped.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.RxDropped)
+		})},
+	{"docker_container_network_transmit_dropped_total", metrics.TypeCounter, "Sent packets which were dropped.",
+		single(func(s *types.Stats) float64 {
+			return float64(s.Network.TxDropped)
+		})},
+	{"docker_container_blkio_service_bytes_total", metrics.TypeCounter, "Bytes transferred to and from block devices, by device and operation.",
+		blkio("service_bytes")},
+	{"docker_container_blkio_serviced_total", metrics.TypeCounter, "I/O operations on block devices, by device and operation.",
+		blkio("serviced")},
+}
+
+func (daemon *Daemon) writeContainerStates(w metrics.Sink) {
+	states := make(map[string]int)
+	for _, container := range daemon.List() {
+		states[container.State.StateString()]++
+	}
+	w.Family("docker_containers", metrics.TypeGauge, "Number of containers by state.")
+	for _, state := range []string{"running", "paused", "restarting", "exited", "dead"} {
+		w.Sample("docker_containers", metrics.Labels{"state": state}, float64(states[state]))
+	}
+}
+
+func (daemon *Daemon) writeContainerMetrics(w metrics.Sink) {
+	type containerStats struct {
+		labels metrics.Labels
+		stats  *types.Stats
+	}
+	var collected []containerStats
+	for _, container := range daemon.List() {
+		if !container.IsRunning() {
+			continue
+		}
+		stats, err := daemon.statsCollector.latest(container)
+		if err != nil {
+			if err != execdriver.ErrNotRunning {
+				log.Errorf("collecting stats for %s: %v", container.ID, err)
+			}
+			continue
+		}
+		ss := convertResourceStats(stats)
+		collected = append(collected, containerStats{
+			labels: metrics.Labels{
+				"id":    container.ID,
+				"name":  strings.TrimPrefix(container.Name, "/"),
+				"image": container.Config.Image,
+			},
+			stats: ss,
+		})
+	}
+	for _, m := range containerMetrics {
+		w.Family(m.name, m.typ, m.help)
+		for _, c := range collected {
+			for _, s := range m.values(c.stats, c.labels) {
+				w.Sample(m.name, s.labels, s.value)
+			}
+		}
+	}
+}
+
+func (daemon *Daemon) writeImageMetrics(w metrics.Sink) {
+	images, err := daemon.Graph().Map()
+	if err != nil {
+		log.Errorf("listing images for metrics: %v", err)
+		return
+	}
+	var size int64
+	for _, img := range images {
+		if img.Size > 0 {
+			size += img.Size
+		}
+	}
+	w.Family("docker_images", metrics.TypeGauge, "Number of image layers.")
+	w.Sample("docker_images", nil, float64(len(images)))
+	w.Family("docker_images_size_bytes", metrics.TypeGauge, "Total size of image layers.")
+	w.Sample("docker_images_size_bytes", nil, float64(size))
+}
+
+func (daemon *Daemon) writeStorageMetrics(w metrics.Sink) {
+	w.Family("docker_graphdriver_info", metrics.TypeGauge, "Storage driver of the daemon, always 1.")
+	w.Sample("docker_graphdriver_info", metrics.Labels{"driver": daemon.GraphDriver().String()}, 1)
+
+	driver := daemon.devmapperDriver()
+	if driver == nil {
+		return
+	}
+	dataUsed, dataTotal, metadataUsed, metadataTotal := driver.ThinPoolUsage()
+	w.Family("docker_thinpool_used_bytes", metrics.TypeGauge, "Space used in the devicemapper thin pool.")
+	w.Sample("docker_thinpool_used_bytes", metrics.Labels{"space": "data"}, float64(dataUsed))
+	w.Sample("docker_thinpool_used_bytes", metrics.Labels{"space": "metadata"}, float64(metadataUsed))
+	w.Family("docker_thinpool_total_bytes", metrics.TypeGauge, "Size of the devicemapper thin pool.")
+	w.Sample("docker_thinpool_total_bytes", metrics.Labels{"space": "data"}, float64(dataTotal))
+	w.Sample("docker_thinpool_total_bytes", metrics.Labels{"space": "metadata"}, float64(metadataTotal))
+}
+
+func writeFixedIPMetrics(w metrics.Sink) {
+	var allocated, free int
+	for _, cid := range ipallocator.FixedIP() {
+		if cid == ipallocator.FakeContainerId {
+			free++
+		} else {
+			allocated++
+		}
+	}
+	w.Family("docker_fixed_ips", metrics.TypeGauge, "Number of registered fixed IPs by state.")
+	w.Sample("docker_fixed_ips", metrics.Labels{"state": "allocated"}, float64(allocated))
+	w.Sample("docker_fixed_ips", metrics.Labels{"state": "free"}, float64(free))
+}
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/metrics"
+)
+
+// daemonMetricsName is the name given to the prefix template for the metrics
+// of the daemon, container names can't start with '_'
+const daemonMetricsName = "_daemon"
+
+// metricsExporter pushes the metrics of the daemon, and of the containers
+// sampled by the stats collector, to a StatsD or Graphite server
+type metricsExporter struct {
+	daemon   *Daemon
+	pusher   *metrics.Pusher
+	prefix   *template.Template
+	interval time.Duration
+	last     time.Time
+	// busy is full while a push is running, a push is skipped rather than
+	// queued behind a slow server
+	busy chan struct{}
+}
+
+// metricsPrefixData is given to the metrics prefix template, its values are
+// escaped to be used as metric path elements
+type metricsPrefixData struct {
+	ID     string
+	Name   string
+	Image  string
+	Labels map[string]string
+}
+
+func newMetricsExporter(daemon *Daemon, target, prefix string, interval time.Duration) (*metricsExporter, error) {
+	pusher, err := metrics.NewPusher(target)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("prefix").Parse(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsExporter{
+		daemon:   daemon,
+		pusher:   pusher,
+		prefix:   tmpl,
+		interval: interval,
+		busy:     make(chan struct{}, 1),
+	}, nil
+}
+
+// collected is called by the stats collector with the stats it collected at
+// t, the metrics are pushed once per interval
+func (e *metricsExporter) collected(stats map[*Container]*execdriver.ResourceStats, t time.Time) {
+	if t.Sub(e.last) < e.interval {
+		return
+	}
+	select {
+	case e.busy <- struct{}{}:
+	default:
+		log.Debugf("Previous metrics push is still running, skipping")
+		return
+	}
+	e.last = t
+	go func() {
+		defer func() { <-e.busy }()
+		sink := &pushSink{}
+		e.containerSamples(sink, stats)
+		e.daemonSamples(sink)
+		if err := e.pusher.Push(sink.samples, t); err != nil {
+			log.Errorf("Error pushing metrics: %s", err)
+		}
+	}()
+}
+
+func (e *metricsExporter) containerSamples(sink *pushSink, stats map[*Container]*execdriver.ResourceStats) {
+	for container, rs := range stats {
+		prefix, err := e.renderPrefix(metricsPrefixData{
+			ID:     container.ID,
+			Name:   strings.TrimPrefix(container.Name, "/"),
+			Image:  container.Config.Image,
+			Labels: container.Config.Labels,
+		})
+		if err != nil {
+			log.Errorf("Error rendering metrics prefix of %s: %s", container.ID, err)
+			continue
+		}
+		sink.prefix, sink.trim = prefix, "docker_container_"
+		ss := convertResourceStats(rs)
+		for _, m := range containerMetrics {
+			for _, s := range m.values(ss, nil) {
+				sink.Sample(m.name, s.labels, s.value)
+			}
+		}
+	}
+}
+
+func (e *metricsExporter) daemonSamples(sink *pushSink) {
+	prefix, err := e.renderPrefix(metricsPrefixData{ID: e.daemon.ID, Name: daemonMetricsName})
+	if err != nil {
+		log.Errorf("Error rendering metrics prefix of the daemon: %s", err)
+		return
+	}
+	sink.prefix, sink.trim = prefix, "docker_"
+	e.daemon.writeContainerStates(sink)
+	e.daemon.writeImageMetrics(sink)
+	e.daemon.writeStorageMetrics(sink)
+	writeFixedIPMetrics(sink)
+}
+
+// renderPrefix executes the prefix template with the escaped values of data,
+// the empty elements of the result are dropped
+func (e *metricsExporter) renderPrefix(data metricsPrefixData) (string, error) {
+	escaped := metricsPrefixData{
+		ID:     metrics.EscapePath(data.ID),
+		Name:   metrics.EscapePath(data.Name),
+		Image:  metrics.EscapePath(data.Image),
+		Labels: make(map[string]string, len(data.Labels)),
+	}
+	for k, v := range data.Labels {
+		escaped.Labels[k] = metrics.EscapePath(v)
+	}
+	var buf bytes.Buffer
+	if err := e.prefix.Execute(&buf, escaped); err != nil {
+		return "", err
+	}
+	var elements []string
+	for _, el := range strings.Split(buf.String(), ".") {
+		if el != "" {
+			elements = append(elements, el)
+		}
+	}
+	return strings.Join(elements, "."), nil
+}
+
+// pushSink turns samples into metric paths, made of the prefix, the name of
+// the metric without trim and the values of the labels ordered by name
+type pushSink struct {
+	prefix  string
+	trim    string
+	samples []metrics.PushSample
+}
+
+func (s *pushSink) Family(name, typ, help string) {}
+
+func (s *pushSink) Sample(name string, labels metrics.Labels, value float64) {
+	path := []string{strings.TrimPrefix(name, s.trim)}
+	if s.prefix != "" {
+		path = append([]string{s.prefix}, path...)
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path = append(path, metrics.EscapePath(labels[name]))
+	}
+	s.samples = append(s.samples, metrics.PushSample{Path: strings.Join(path, "."), Value: value})
+}
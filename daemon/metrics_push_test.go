@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/metrics"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups"
+)
+
+func TestMetricsExporterContainerSamples(t *testing.T) {
+	e := &metricsExporter{
+		prefix: template.Must(template.New("prefix").Parse(`docker.{{index .Labels "team"}}.{{.Name}}`)),
+	}
+	c := &Container{
+		ID:   "8bc4a3dbe0a2",
+		Name: "/web.1",
+		Config: &runconfig.Config{
+			Image:  "redis",
+			Labels: map[string]string{"team": "ops"},
+		},
+	}
+	cs := cgroups.NewStats()
+	cs.MemoryStats.Usage = 4096
+	cs.BlkioStats.IoServicedRecursive = []cgroups.BlkioStatEntry{{Major: 8, Minor: 0, Op: "Read", Value: 7}}
+	sink := &pushSink{}
+	e.containerSamples(sink, map[*Container]*execdriver.ResourceStats{
+		c: {Stats: &libcontainer.Stats{CgroupStats: cs}},
+	})
+
+	samples := make(map[string]float64)
+	for _, s := range sink.samples {
+		samples[s.Path] = s.Value
+	}
+	if v, ok := samples["docker.ops.web_1.memory_usage_bytes"]; !ok || v != 4096 {
+		t.Fatalf("Expected memory usage 4096, got %v in %v", v, samples)
+	}
+	if v, ok := samples["docker.ops.web_1.blkio_serviced_total.8_0.read"]; !ok || v != 7 {
+		t.Fatalf("Expected 7 serviced reads, got %v in %v", v, samples)
+	}
+
+	// a label the daemon doesn't have leaves no empty path element
+	prefix, err := e.renderPrefix(metricsPrefixData{Name: daemonMetricsName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink = &pushSink{prefix: prefix, trim: "docker_"}
+	sink.Sample("docker_containers", metrics.Labels{"state": "running"}, 2)
+	if s := sink.samples[0]; s.Path != "docker._daemon.containers.running" || s.Value != 2 {
+		t.Fatalf("Unexpected daemon sample %+v", s)
+	}
+}
@@ -3,7 +3,9 @@ package daemon
 import (
 	"io"
 	"os/exec"
+	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -12,7 +14,19 @@ import (
 	"github.com/docker/docker/runconfig"
 )
 
-const defaultTimeIncrement = 100
+const (
+	// defaultRestartDelay is the delay before the first restart of a container
+	defaultRestartDelay = 100 * time.Millisecond
+	// defaultResetWindow is the time a container must run for its restart
+	// delay to be reset
+	defaultResetWindow = 10 * time.Second
+)
+
+// Reasons of the restarts of a container, in its RestartReason
+const (
+	restartReasonExited    = "exited"
+	restartReasonUnhealthy = "unhealthy"
+)
 
 // containerMonitor monitors the execution of a container's main process.
 // If a restart policy is specified for the container the monitor will ensure that the
@@ -40,28 +54,32 @@ type containerMonitor struct {
 	startSignal chan struct{}
 
 	// stopChan is used to signal to the monitor whenever there is a wait for the
-	// next restart so that the timeIncrement is not honored and the user is not
+	// next restart so that the restartDelay is not honored and the user is not
 	// left waiting for nothing to happen during this time
 	stopChan chan struct{}
 
-	// timeIncrement is the amount of time to wait between restarts
-	// this is in milliseconds
-	timeIncrement int
+	// restartDelay is the amount of time to wait before the next restart
+	restartDelay time.Duration
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// killedUnhealthy is set when the monitor killed the container's process
+	// because it was unhealthy
+	killedUnhealthy bool
 }
 
 // newContainerMonitor returns an initialized containerMonitor for the provided container
 // honoring the provided restart policy
 func newContainerMonitor(container *Container, policy runconfig.RestartPolicy) *containerMonitor {
-	return &containerMonitor{
+	m := &containerMonitor{
 		container:     container,
 		restartPolicy: policy,
-		timeIncrement: defaultTimeIncrement,
 		stopChan:      make(chan struct{}),
 		startSignal:   make(chan struct{}),
 	}
+	m.restartDelay = m.initialDelay()
+	return m
 }
 
 // Stop signals to the container monitor that it should stop monitoring the container
@@ -119,6 +137,7 @@ func (m *containerMonitor) Start() error {
 
 	// reset the restart count
 	m.container.RestartCount = -1
+	m.container.RestartReason = ""
 
 	for {
 		m.container.RestartCount++
@@ -131,9 +150,18 @@ func (m *containerMonitor) Start() error {
 
 		pipes := execdriver.NewPipes(m.container.stdin, m.container.stdout, m.container.stderr, m.container.Config.OpenStdin)
 
-		m.container.LogEvent("start")
+		attributes := map[string]string{
+			"restartCount": strconv.Itoa(m.container.RestartCount),
+		}
+		if m.container.RestartReason != "" {
+			attributes["restartReason"] = m.container.RestartReason
+		}
+		m.container.LogEventWithAttributes("start", attributes)
 
+		m.mux.Lock()
 		m.lastStartTime = time.Now()
+		m.killedUnhealthy = false
+		m.mux.Unlock()
 
 		if exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback); err != nil {
 			// if we receive an internal error from the initial start of a container then lets
@@ -153,12 +181,16 @@ func (m *containerMonitor) Start() error {
 
 		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
 
-		if m.shouldRestart(exitStatus.ExitCode) {
+		// the health checks may still run, the restart and its reason are
+		// decided on the same snapshot
+		killedUnhealthy := m.wasKilledUnhealthy()
+		if m.shouldRestart(exitStatus.ExitCode, killedUnhealthy) {
 			m.container.SetRestarting(&exitStatus)
+			m.container.RestartReason = restartReason(killedUnhealthy)
 			if exitStatus.OOMKilled {
 				m.container.LogEvent("oom")
 			}
-			m.container.LogEvent("die")
+			m.logDieEvent(exitStatus)
 			m.resetContainer(true)
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
@@ -175,24 +207,52 @@ func (m *containerMonitor) Start() error {
 		if exitStatus.OOMKilled {
 			m.container.LogEvent("oom")
 		}
-		m.container.LogEvent("die")
+		m.logDieEvent(exitStatus)
 		m.resetContainer(true)
 		return err
 	}
 }
 
+// logDieEvent logs the "die" event with the exit code and restart count of
+// the container
+func (m *containerMonitor) logDieEvent(exitStatus execdriver.ExitStatus) {
+	m.container.LogEventWithAttributes("die", map[string]string{
+		"exitCode":     strconv.Itoa(exitStatus.ExitCode),
+		"oomKilled":    strconv.FormatBool(exitStatus.OOMKilled),
+		"restartCount": strconv.Itoa(m.container.RestartCount),
+	})
+}
+
+// initialDelay returns the delay before the first restart of the container,
+// from its restart policy or the default
+func (m *containerMonitor) initialDelay() time.Duration {
+	if m.restartPolicy.Delay > 0 {
+		return m.restartPolicy.Delay
+	}
+	return defaultRestartDelay
+}
+
 // resetMonitor resets the stateful fields on the containerMonitor based on the
 // previous runs success or failure.  Regardless of success, if the container had
-// an execution time of more than 10s then reset the timer back to the default
+// an execution time of more than the reset window of its restart policy then
+// reset the delay back to the initial one
 func (m *containerMonitor) resetMonitor(successful bool) {
-	executionTime := time.Now().Sub(m.lastStartTime).Seconds()
+	executionTime := time.Now().Sub(m.lastStartTime)
 
-	if executionTime > 10 {
-		m.timeIncrement = defaultTimeIncrement
+	resetWindow := m.restartPolicy.ResetWindow
+	if resetWindow <= 0 {
+		resetWindow = defaultResetWindow
+	}
+	if executionTime > resetWindow {
+		m.restartDelay = m.initialDelay()
 	} else {
 		// otherwise we need to increment the amount of time we wait before restarting
-		// the process.  We will build up by multiplying the increment by 2
-		m.timeIncrement *= 2
+		// the process.  We will build up by multiplying the delay by 2, up to
+		// the maximum delay of the restart policy
+		m.restartDelay *= 2
+		if max := m.restartPolicy.MaxDelay; max > 0 && m.restartDelay > max {
+			m.restartDelay = max
+		}
 	}
 
 	// the container exited successfully so we need to reset the failure counter
@@ -203,18 +263,19 @@ func (m *containerMonitor) resetMonitor(successful bool) {
 	}
 }
 
-// waitForNextRestart waits with the default time increment to restart the container unless
+// waitForNextRestart waits with the restart delay to restart the container unless
 // a user or docker asks for the container to be stopped
 func (m *containerMonitor) waitForNextRestart() {
 	select {
-	case <-time.After(time.Duration(m.timeIncrement) * time.Millisecond):
+	case <-time.After(m.restartDelay):
 	case <-m.stopChan:
 	}
 }
 
 // shouldRestart checks the restart policy and applies the rules to determine if
-// the container's process should be restarted
-func (m *containerMonitor) shouldRestart(exitCode int) bool {
+// the container's process should be restarted. killedUnhealthy tells whether
+// the process was killed by the monitor because it was unhealthy.
+func (m *containerMonitor) shouldRestart(exitCode int, killedUnhealthy bool) bool {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -224,9 +285,9 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 	}
 
 	switch m.restartPolicy.Name {
-	case "always":
+	case "always", "unless-stopped":
 		return true
-	case "on-failure":
+	case "on-failure", "on-unhealthy":
 		// the default value of 0 for MaximumRetryCount means that we will not enforce a maximum count
 		if max := m.restartPolicy.MaximumRetryCount; max != 0 && m.failureCount > max {
 			log.Debugf("stopping restart of container %s because maximum failure could of %d has been reached",
@@ -234,12 +295,62 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 			return false
 		}
 
-		return exitCode != 0
+		return exitCode != 0 || killedUnhealthy
 	}
 
 	return false
 }
 
+// wasKilledUnhealthy returns whether the monitor killed the container's
+// process because it was unhealthy
+func (m *containerMonitor) wasKilledUnhealthy() bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.killedUnhealthy
+}
+
+// restartReason returns why the container's process is being restarted
+func restartReason(killedUnhealthy bool) string {
+	if killedUnhealthy {
+		return restartReasonUnhealthy
+	}
+	return restartReasonExited
+}
+
+// unhealthy is called by the health checks of the container when it is
+// unhealthy. With the on-unhealthy restart policy the container's process is
+// killed, to be restarted, once the grace period after its start is over.
+func (m *containerMonitor) unhealthy() {
+	m.mux.Lock()
+	if m.restartPolicy.Name != "on-unhealthy" || m.shouldStop || m.killedUnhealthy ||
+		time.Now().Sub(m.lastStartTime) < m.restartPolicy.GracePeriod {
+		m.mux.Unlock()
+		return
+	}
+	// set before the kill, so the exit of the process is seen as caused by
+	// it, and unset if nothing was killed
+	m.killedUnhealthy = true
+	m.mux.Unlock()
+
+	container := m.container
+	container.Lock()
+	defer container.Unlock()
+	killed := false
+	if container.Running && !container.Paused && !container.Restarting {
+		log.Infof("Killing unhealthy container %s to restart it", common.TruncateID(container.ID))
+		if err := container.daemon.Kill(container, int(syscall.SIGKILL)); err != nil {
+			log.Errorf("Error killing unhealthy container %s: %s", container.ID, err)
+		} else {
+			killed = true
+		}
+	}
+	if !killed {
+		m.mux.Lock()
+		m.killedUnhealthy = false
+		m.mux.Unlock()
+	}
+}
+
 // callback ensures that the container's state is properly updated after we
 // received ack from the execution drivers
 func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid int) {
@@ -253,6 +364,7 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 	}
 
 	m.container.setRunning(pid)
+	m.container.initHealthMonitor(m.unhealthy)
 
 	// signal that the process has started
 	// close channel only if not closed
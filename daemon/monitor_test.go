@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestShouldRestartOnUnhealthy(t *testing.T) {
+	c := &Container{ID: "8bc4a3dbe0a2", State: NewState()}
+	m := newContainerMonitor(c, runconfig.RestartPolicy{Name: "on-unhealthy", MaximumRetryCount: 2, GracePeriod: time.Hour})
+	m.lastStartTime = time.Now()
+
+	if m.shouldRestart(0, false) {
+		t.Fatal("Expected no restart after a successful exit")
+	}
+	if !m.shouldRestart(1, false) {
+		t.Fatal("Expected a restart after a failed exit")
+	}
+
+	m.unhealthy()
+	if m.killedUnhealthy {
+		t.Fatal("Expected an unhealthy container not to be killed during the grace period")
+	}
+	m.restartPolicy.GracePeriod = 0
+	m.unhealthy()
+	if m.killedUnhealthy {
+		t.Fatal("Expected a container which isn't running not to be killed")
+	}
+	if m.shouldRestart(0, m.wasKilledUnhealthy()) {
+		t.Fatal("Expected no restart after a successful exit of a container which wasn't killed")
+	}
+
+	// as after the kill of a running container
+	m.killedUnhealthy = true
+	if !m.shouldRestart(0, m.wasKilledUnhealthy()) {
+		t.Fatal("Expected a restart of a container killed because it was unhealthy")
+	}
+	if reason := restartReason(m.wasKilledUnhealthy()); reason != restartReasonUnhealthy {
+		t.Fatalf("Expected restart reason %q, got %q", restartReasonUnhealthy, reason)
+	}
+
+	m.failureCount = 3
+	if m.shouldRestart(1, false) {
+		t.Fatal("Expected no restart after the maximum retry count")
+	}
+
+	m = newContainerMonitor(c, runconfig.RestartPolicy{Name: "on-failure"})
+	m.unhealthy()
+	if m.killedUnhealthy {
+		t.Fatal("Expected only the on-unhealthy policy to kill unhealthy containers")
+	}
+}
+
+func TestMonitorRestartDelay(t *testing.T) {
+	c := &Container{ID: "8bc4a3dbe0a2", State: NewState()}
+	m := newContainerMonitor(c, runconfig.RestartPolicy{Name: "always"})
+	if m.restartDelay != defaultRestartDelay {
+		t.Fatalf("Expected the default delay %s, got %s", defaultRestartDelay, m.restartDelay)
+	}
+
+	m = newContainerMonitor(c, runconfig.RestartPolicy{
+		Name:        "unless-stopped",
+		Delay:       time.Second,
+		MaxDelay:    3 * time.Second,
+		ResetWindow: time.Minute,
+	})
+	m.lastStartTime = time.Now()
+	for _, expected := range []time.Duration{2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		m.resetMonitor(false)
+		if m.restartDelay != expected {
+			t.Fatalf("Expected a delay of %s, got %s", expected, m.restartDelay)
+		}
+	}
+	if m.failureCount != 3 {
+		t.Fatalf("Expected 3 failures, got %d", m.failureCount)
+	}
+
+	// a container which ran longer than the reset window gets the initial delay
+	m.lastStartTime = time.Now().Add(-2 * time.Minute)
+	m.resetMonitor(true)
+	if m.restartDelay != time.Second || m.failureCount != 0 {
+		t.Fatalf("Expected the delay and failures to be reset, got %s and %d", m.restartDelay, m.failureCount)
+	}
+	if !m.shouldRestart(0, false) {
+		t.Fatal("Expected the unless-stopped policy to restart the container")
+	}
+	m.ExitOnNext()
+	if m.shouldRestart(1, false) {
+		t.Fatal("Expected no restart of a stopped container")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/daemon/networkdriver"
 	"github.com/docker/docker/daemon/networkdriver/ipallocator"
 	"github.com/docker/docker/daemon/networkdriver/portmapper"
@@ -625,12 +626,23 @@ func Allocate(job *engine.Job) engine.Status {
 		restrictIPs   = job.Getenv("RestrictIP")
 		markNum       = job.GetenvInt64("MarkNum")
 	)
+	attributes := map[string]string{"container": id}
+	if requestedIP != nil {
+		attributes["requested"] = requestedIP.String()
+	}
 	if runconfig.NetworkMode(mode).IsIP() {
+		attributes["pool"] = "fixed"
 		ip, err = ipallocator.RequestFixedIP(id, requestedIP)
+		if err == ipallocator.ErrFixedIPAlreadyAllocated {
+			// tell who holds the double-booked ip
+			attributes["owner"] = ipallocator.FixedIP()[requestedIP.String()]
+		}
 	} else {
+		attributes["pool"] = bridgeIPv4Network.String()
 		ip, err = ipallocator.RequestIP(bridgeIPv4Network, requestedIP)
 	}
 	if err != nil {
+		logEvent(job.Eng, types.IPEventType, "ip.allocate", attributes["requested"], attributes, err)
 		return job.Error(err)
 	}
 	if enableIPTables {
@@ -638,12 +650,18 @@ func Allocate(job *engine.Job) engine.Status {
 		if runconfig.NetworkMode(mode).IsIP() {
 			bridgeName = DefaultFixedIpNetworkBridge
 		}
-		if err := setupRestrictIPTables(restrictIPs, ip.String(), bridgeName); err != nil {
-			return job.Error(err)
+		if restrictIPs != "" {
+			err := setupRestrictIPTables(restrictIPs, ip.String(), bridgeName)
+			logPolicyEvent(job.Eng, id, "add", "restrict", restrictIPs, ip, bridgeName, err)
+			if err != nil {
+				return job.Error(err)
+			}
 		}
 		if markNum != 0 {
-			if err := setupMarkIPTables(ip.String(), bridgeName, markNum); err != nil {
-				return job.Error(err);
+			err := setupMarkIPTables(ip.String(), bridgeName, markNum)
+			logPolicyEvent(job.Eng, id, "add", "mark", strconv.FormatInt(markNum, 10), ip, bridgeName, err)
+			if err != nil {
+				return job.Error(err)
 			}
 		}
 	}
@@ -667,9 +685,11 @@ func Allocate(job *engine.Job) engine.Status {
 		globalIPv6, err = ipallocator.RequestIP(globalIPv6Network, requestedIPv6)
 		if err != nil {
 			log.Errorf("Allocator: RequestIP v6: %v", err)
+			logEvent(job.Eng, types.IPEventType, "ip.allocate", ip.String(), attributes, err)
 			return job.Error(err)
 		}
 		log.Infof("Allocated IPv6 %s", globalIPv6)
+		attributes["ipv6"] = globalIPv6.String()
 	}
 
 	out := engine.Env{}
@@ -709,6 +729,8 @@ func Allocate(job *engine.Job) engine.Status {
 		IP:   ip,
 		IPv6: globalIPv6,
 	})
+	attributes["bridge"] = out.Get("Bridge")
+	logEvent(job.Eng, types.IPEventType, "ip.allocate", ip.String(), attributes, nil)
 
 	out.WriteTo(job.Stdout)
 
@@ -733,40 +755,47 @@ func Release(job *engine.Job) engine.Status {
 			log.Infof("Unable to unmap port %s: %s", nat, err)
 		}
 	}
+	var (
+		bridgeName = DefaultNetworkBridge
+		attributes = map[string]string{"container": id}
+		err        error
+	)
 	if runconfig.NetworkMode(mode).IsIP() {
-		if err := ipallocator.ReleaseFixedIP(containerInterface.IP); err != nil {
+		bridgeName = DefaultFixedIpNetworkBridge
+		attributes["pool"] = "fixed"
+		if err = ipallocator.ReleaseFixedIP(containerInterface.IP); err != nil {
 			log.Infof("Unable to release fixed ip %s", err)
 		}
-		if enableIPTables {
-			if err1 := removeRestrictIPTables(restrictIPs, containerInterface.IP.String(), DefaultFixedIpNetworkBridge); err1 != nil {
-				log.Infof("Unable to remove iptables rule %s", err1)
-			}
-			if markNum != 0 {
-				if err := removeMarkIPTables(containerInterface.IP.String(), DefaultFixedIpNetworkBridge, markNum); err != nil {
-					log.Infof("Unable to remove iptables rule %s", err)
-				}
-			}
-		}
 	} else {
-		if err := ipallocator.ReleaseIP(bridgeIPv4Network, containerInterface.IP); err != nil {
+		attributes["pool"] = bridgeIPv4Network.String()
+		if err = ipallocator.ReleaseIP(bridgeIPv4Network, containerInterface.IP); err != nil {
 			log.Infof("Unable to release IPv4 %s", err)
 		}
-		if enableIPTables {
-			if err1 := removeRestrictIPTables(restrictIPs, containerInterface.IP.String(), DefaultNetworkBridge); err1 != nil {
-				log.Infof("Unable to remove iptables rule %s", err1)
+	}
+	if enableIPTables {
+		if restrictIPs != "" {
+			err := removeRestrictIPTables(restrictIPs, containerInterface.IP.String(), bridgeName)
+			if err != nil {
+				log.Infof("Unable to remove iptables rule %s", err)
 			}
-			if markNum != 0 {
-				if err := removeMarkIPTables(containerInterface.IP.String(), DefaultNetworkBridge, markNum); err != nil {
-					log.Infof("Unable to remove iptables rule %s", err)
-				}
+			logPolicyEvent(job.Eng, id, "remove", "restrict", restrictIPs, containerInterface.IP, bridgeName, err)
+		}
+		if markNum != 0 {
+			err := removeMarkIPTables(containerInterface.IP.String(), bridgeName, markNum)
+			if err != nil {
+				log.Infof("Unable to remove iptables rule %s", err)
 			}
+			logPolicyEvent(job.Eng, id, "remove", "mark", strconv.FormatInt(markNum, 10), containerInterface.IP, bridgeName, err)
 		}
 	}
 	if globalIPv6Network != nil {
 		if err := ipallocator.ReleaseIP(globalIPv6Network, containerInterface.IPv6); err != nil {
 			log.Infof("Unable to release IPv6 %s", err)
 		}
+		attributes["ipv6"] = containerInterface.IPv6.String()
 	}
+	attributes["bridge"] = bridgeName
+	logEvent(job.Eng, types.IPEventType, "ip.release", containerInterface.IP.String(), attributes, err)
 	return engine.StatusOK
 }
 
@@ -823,7 +852,14 @@ func AllocatePort(job *engine.Job) engine.Status {
 		job.Logf("Failed to allocate and map port: %s, retry: %d", err, i+1)
 	}
 
+	attributes := map[string]string{
+		"hostIP":        ip.String(),
+		"hostPort":      strconv.Itoa(hostPort),
+		"containerPort": strconv.Itoa(containerPort),
+		"proto":         proto,
+	}
 	if err != nil {
+		logEvent(job.Eng, types.NetworkEventType, "port.bind", id, attributes, err)
 		return job.Error(err)
 	}
 
@@ -838,6 +874,8 @@ func AllocatePort(job *engine.Job) engine.Status {
 		out.Set("HostIP", netAddr.IP.String())
 		out.SetInt("HostPort", netAddr.Port)
 	}
+	attributes["hostIP"], attributes["hostPort"] = out.Get("HostIP"), out.Get("HostPort")
+	logEvent(job.Eng, types.NetworkEventType, "port.bind", id, attributes, nil)
 	if _, err := out.WriteTo(job.Stdout); err != nil {
 		return job.Error(err)
 	}
@@ -890,7 +928,11 @@ func RegisterIP(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
-	if err = ipallocator.RegisterFixedIP(ips); err != nil {
+	err = ipallocator.RegisterFixedIP(ips)
+	for _, ip := range ips {
+		logEvent(job.Eng, types.IPEventType, "ip.register", ip.String(), map[string]string{"pool": "fixed"}, err)
+	}
+	if err != nil {
 		return job.Error(err)
 	}
 	log.Infof("Registered new fixed ip %v", ips)
@@ -902,7 +944,16 @@ func UnRegisterIP(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
-	if err = ipallocator.UnRegisterFixedIP(ips); err != nil {
+	owners := ipallocator.FixedIP()
+	err = ipallocator.UnRegisterFixedIP(ips)
+	for _, ip := range ips {
+		attributes := map[string]string{"pool": "fixed"}
+		if cid, ok := owners[ip.String()]; ok && cid != ipallocator.FakeContainerId {
+			attributes["owner"] = cid
+		}
+		logEvent(job.Eng, types.IPEventType, "ip.unregister", ip.String(), attributes, err)
+	}
+	if err != nil {
 		return job.Error(err)
 	}
 	log.Infof("UnRegistered fixed ip %v", ips)
@@ -1028,3 +1079,28 @@ func removeMarkIPTables(ip, bridge string, num int64) error {
 	return nil
 }
 
+// logEvent publishes a networking event, err is reported in the "error"
+// attribute of failed operations
+func logEvent(eng *engine.Engine, eventType, action, id string, attributes map[string]string, err error) {
+	if err != nil {
+		attributes["error"] = err.Error()
+	}
+	job := eng.Job("log", action, id, "")
+	job.Setenv("Type", eventType)
+	job.SetenvJson("Attributes", attributes)
+	if err := job.Run(); err != nil {
+		log.Errorf("Error logging event %s for %s: %s", action, id, err)
+	}
+}
+
+// logPolicyEvent publishes a policy.update event about the iptables rule
+// (restrict or mark) added or removed for the container id
+func logPolicyEvent(eng *engine.Engine, id, op, rule, value string, ip net.IP, bridge string, err error) {
+	logEvent(eng, types.NetworkEventType, "policy.update", id, map[string]string{
+		"op":     op,
+		"rule":   rule,
+		"value":  value,
+		"ip":     ip.String(),
+		"bridge": bridge,
+	}, err)
+}
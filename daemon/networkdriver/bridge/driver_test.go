@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/docker/docker/daemon/networkdriver/ipallocator"
 	"github.com/docker/docker/daemon/networkdriver/portmapper"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/iptables"
@@ -277,3 +278,51 @@ func TestLinkContainers(t *testing.T) {
 	}
 
 }
+
+func TestFixedIPEvents(t *testing.T) {
+	eng := engine.New()
+	eng.Logging = false
+
+	type event struct {
+		action, id string
+		attributes map[string]string
+	}
+	var events []event
+	eng.Register("log", func(job *engine.Job) engine.Status {
+		if job.Getenv("Type") != "ip" {
+			t.Fatalf("Wrong event type %q", job.Getenv("Type"))
+		}
+		ev := event{action: job.Args[0], id: job.Args[1]}
+		job.GetenvJson("Attributes", &ev.attributes)
+		events = append(events, ev)
+		return engine.StatusOK
+	})
+
+	if res := RegisterIP(eng.Job("register_ip", "10.1.2.3")); res != engine.StatusOK {
+		t.Fatal("Failed to register fixed ip")
+	}
+	// double registration is reported with the error
+	if res := RegisterIP(eng.Job("register_ip", "10.1.2.3")); res == engine.StatusOK {
+		t.Fatal("Registering a fixed ip twice must fail")
+	}
+	if _, err := ipallocator.RequestFixedIP("container_id", net.ParseIP("10.1.2.3")); err != nil {
+		t.Fatal(err)
+	}
+	// the container using the ip is reported when it can't be unregistered
+	if res := UnRegisterIP(eng.Job("unregister_ip", "10.1.2.3")); res == engine.StatusOK {
+		t.Fatal("Unregistering an allocated fixed ip must fail")
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %v", events)
+	}
+	if events[0].action != "ip.register" || events[0].id != "10.1.2.3" || events[0].attributes["error"] != "" {
+		t.Fatalf("Wrong register event: %v", events[0])
+	}
+	if events[1].action != "ip.register" || events[1].attributes["error"] == "" {
+		t.Fatalf("Wrong failed register event: %v", events[1])
+	}
+	if events[2].action != "ip.unregister" || events[2].attributes["owner"] != "container_id" || events[2].attributes["error"] == "" {
+		t.Fatalf("Wrong failed unregister event: %v", events[2])
+	}
+}
@@ -19,6 +19,7 @@ func (daemon *Daemon) ContainerRestart(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
+	container.setManuallyStopped(false)
 	if err := container.Restart(int(t)); err != nil {
 		return job.Errorf("Cannot restart container %s: %s\n", name, err)
 	}
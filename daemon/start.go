@@ -39,6 +39,7 @@ func (daemon *Daemon) ContainerStart(job *engine.Job) engine.Status {
 			return job.Error(err)
 		}
 	}
+	container.setManuallyStopped(false)
 	if err := container.Start(); err != nil {
 		container.LogEvent("die")
 		return job.Errorf("Cannot start container %s: %s", name, err)
@@ -53,6 +54,9 @@ func (daemon *Daemon) setHostConfig(container *Container, hostConfig *runconfig.
 	if err := parseSecurityOpt(container, hostConfig); err != nil {
 		return err
 	}
+	if err := daemon.resolveSeccompProfile(container, hostConfig); err != nil {
+		return err
+	}
 
 	// FIXME: this should be handled by the volume subsystem
 	// Validate the HostConfig binds. Make sure that:
@@ -22,6 +22,7 @@ type State struct {
 	Error             string // contains last known error when starting the container
 	StartedAt         time.Time
 	FinishedAt        time.Time
+	Health            *Health `json:",omitempty"`
 	waitChan          chan struct{}
 }
 
@@ -41,7 +42,7 @@ func (s *State) String() string {
 			return fmt.Sprintf("Restarting (%d) %s ago", s.ExitCode, units.HumanDuration(time.Now().UTC().Sub(s.FinishedAt)))
 		}
 
-		return fmt.Sprintf("Up %s", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)))
+		return fmt.Sprintf("Up %s%s", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)), s.healthString())
 	}
 
 	if s.removalInProgress {
@@ -173,6 +174,7 @@ func (s *State) SetStopped(exitStatus *execdriver.ExitStatus) {
 }
 
 func (s *State) setStopped(exitStatus *execdriver.ExitStatus) {
+	s.stopHealthcheck()
 	s.Running = false
 	s.Restarting = false
 	s.Pid = 0
@@ -187,6 +189,7 @@ func (s *State) setStopped(exitStatus *execdriver.ExitStatus) {
 // in the middle of a stop and being restarted again
 func (s *State) SetRestarting(exitStatus *execdriver.ExitStatus) {
 	s.Lock()
+	s.stopHealthcheck()
 	// we should consider the container running when it is restarting because of
 	// all the checks in docker around rm/stop/etc
 	s.Running = true
@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -102,3 +103,43 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 
 }
+
+func TestStateHealth(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+	stop := make(chan struct{})
+	s.Health = &Health{Status: HealthStarting, stop: stop}
+	if status := s.String(); !strings.HasSuffix(status, " (health: starting)") {
+		t.Fatalf("Unexpected status %q", status)
+	}
+
+	failed := &HealthcheckResult{ExitCode: 1}
+	if status, changed := s.updateHealth(failed, stop, 2); changed || status != HealthStarting {
+		t.Fatalf("Expected to still be starting after one failure, got %q", status)
+	}
+	if status, changed := s.updateHealth(failed, stop, 2); !changed || status != Unhealthy {
+		t.Fatalf("Expected unhealthy after two failures, got %q", status)
+	}
+	if status, changed := s.updateHealth(&HealthcheckResult{}, stop, 2); !changed || status != Healthy {
+		t.Fatalf("Expected healthy after a success, got %q", status)
+	}
+	if s.Health.FailingStreak != 0 || len(s.Health.Log) != 3 {
+		t.Fatalf("Unexpected health %+v", s.Health)
+	}
+	if status := s.String(); !strings.HasSuffix(status, " (healthy)") {
+		t.Fatalf("Unexpected status %q", status)
+	}
+
+	// results of checks stopped meanwhile are dropped
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
+	if _, changed := s.updateHealth(failed, stop, 2); changed || len(s.Health.Log) != 3 {
+		t.Fatalf("Expected the result to be dropped, got %+v", s.Health)
+	}
+	for i := 0; i < maxHealthLogEntries+2; i++ {
+		s.Health.stop = stop
+		s.updateHealth(&HealthcheckResult{}, stop, 2)
+	}
+	if len(s.Health.Log) != maxHealthLogEntries {
+		t.Fatalf("Expected %d results in the log, got %d", maxHealthLogEntries, len(s.Health.Log))
+	}
+}
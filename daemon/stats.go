@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/daemon/execdriver"
@@ -15,22 +16,82 @@ func (daemon *Daemon) ContainerStats(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
-	enc := json.NewEncoder(job.Stdout)
+	var (
+		// stats are streamed unless the stream env is set to false
+		stream = !job.EnvExists("stream") || job.GetenvBool("stream")
+		enc    = json.NewEncoder(job.Stdout)
+		preCpu *types.CpuStats
+	)
 	for v := range updates {
 		update := v.(*execdriver.ResourceStats)
-		ss := convertToAPITypes(update.Stats)
-		ss.MemoryStats.Limit = uint64(update.MemoryLimit)
-		ss.Read = update.Read
-		ss.CpuStats.SystemUsage = update.SystemUsage
+		ss := convertResourceStats(update)
+		if preCpu != nil {
+			ss.PreCpuStats = *preCpu
+		} else if !stream {
+			// a single sample is only sent along with the previous cpu
+			// stats, so its cpu usage can be computed
+			preCpu = &ss.CpuStats
+			continue
+		}
+		preCpu = &ss.CpuStats
 		if err := enc.Encode(ss); err != nil {
 			// TODO: handle the specific broken pipe
 			daemon.UnsubscribeToContainerStats(job.Args[0], updates)
 			return job.Error(err)
 		}
+		if !stream {
+			daemon.UnsubscribeToContainerStats(job.Args[0], updates)
+			break
+		}
+	}
+	return engine.StatusOK
+}
+
+// ContainerStatsHistory writes the stats the daemon kept of a container, read
+// after the unix timestamp given in the since env, as a JSON array.
+func (daemon *Daemon) ContainerStatsHistory(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	container, err := daemon.Get(job.Args[0])
+	if err != nil {
+		return job.Error(err)
+	}
+	var since time.Time
+	if s := job.GetenvInt64("since"); s != 0 {
+		since = time.Unix(s, 0)
+	}
+	history := daemon.statsCollector.history(container, since)
+	samples := make([]*types.Stats, 0, len(history))
+	for i, update := range history {
+		ss := convertResourceStats(update)
+		if i > 0 {
+			ss.PreCpuStats = samples[i-1].CpuStats
+		}
+		samples = append(samples, ss)
+	}
+	if err := json.NewEncoder(job.Stdout).Encode(samples); err != nil {
+		return job.Error(err)
 	}
 	return engine.StatusOK
 }
 
+// convertResourceStats converts the stats returned by the exec driver to the
+// api specific structs.
+func convertResourceStats(rs *execdriver.ResourceStats) *types.Stats {
+	ss := convertToAPITypes(rs.Stats)
+	ss.Read = rs.Read
+	ss.CpuStats.SystemUsage = rs.SystemUsage
+	ss.MemoryStats.Limit = uint64(rs.MemoryLimit)
+	ss.MemoryStats.OomKills = rs.OomKills
+	ss.PidsStats.Current = rs.Pids
+	if cs := rs.CgroupStats; cs != nil && cs.PidsStats.Current != 0 {
+		// the pids cgroup counts the threads too, like its limit
+		ss.PidsStats = types.PidsStats{Current: cs.PidsStats.Current, Limit: cs.PidsStats.Limit}
+	}
+	return ss
+}
+
 // convertToAPITypes converts the libcontainer.Stats to the api specific
 // structs.  This is done to preserve API compatibility and versioning.
 func convertToAPITypes(ls *libcontainer.Stats) *types.Stats {
@@ -76,10 +137,14 @@ func convertToAPITypes(ls *libcontainer.Stats) *types.Stats {
 		}
 		mem := cs.MemoryStats
 		s.MemoryStats = types.MemoryStats{
-			Usage:    mem.Usage,
-			MaxUsage: mem.MaxUsage,
-			Stats:    mem.Stats,
-			Failcnt:  mem.Failcnt,
+			Usage:      mem.Usage,
+			MaxUsage:   mem.MaxUsage,
+			Stats:      mem.Stats,
+			Failcnt:    mem.Failcnt,
+			Rss:        mem.Stats["rss"],
+			Cache:      mem.Stats["cache"],
+			Swap:       mem.Stats["swap"],
+			MappedFile: mem.Stats["mapped_file"],
 		}
 	}
 	return s
@@ -18,12 +18,16 @@ import (
 // newStatsCollector returns a new statsCollector that collections
 // network and cgroup stats for a registered container at the specified
 // interval.  The collector allows non-running containers to be added
-// and will start processing stats when they are started.
-func newStatsCollector(interval time.Duration) *statsCollector {
+// and will start processing stats when they are started.  The stats of
+// the last history of tracked containers are kept, 0 keeps none.
+func newStatsCollector(interval, history time.Duration) *statsCollector {
 	s := &statsCollector{
-		interval:   interval,
-		publishers: make(map[*Container]*pubsub.Publisher),
-		clockTicks: uint64(system.GetClockTicks()),
+		interval:    interval,
+		historySize: int(history / interval),
+		publishers:  make(map[*Container]*pubsub.Publisher),
+		last:        make(map[*Container]*execdriver.ResourceStats),
+		histories:   make(map[*Container]*statsHistory),
+		clockTicks:  uint64(system.GetClockTicks()),
 	}
 	go s.run()
 	return s
@@ -35,6 +39,47 @@ type statsCollector struct {
 	interval   time.Duration
 	clockTicks uint64
 	publishers map[*Container]*pubsub.Publisher
+	// last holds the latest stats of containers, for metrics scrapes
+	last map[*Container]*execdriver.ResourceStats
+	// histories holds the recent stats of tracked containers, which are
+	// collected while they run even without subscribers if the history is
+	// kept or the metrics are exported
+	historySize int
+	histories   map[*Container]*statsHistory
+	exporter    *metricsExporter
+}
+
+// statsHistory is a ring buffer of the latest stats of a container
+type statsHistory struct {
+	samples []*execdriver.ResourceStats
+	next    int
+	full    bool
+}
+
+func (h *statsHistory) add(stats *execdriver.ResourceStats) {
+	if len(h.samples) == 0 {
+		return
+	}
+	h.samples[h.next] = stats
+	if h.next++; h.next == len(h.samples) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// since returns the samples read after t, oldest first
+func (h *statsHistory) since(t time.Time) []*execdriver.ResourceStats {
+	ordered := h.samples[:h.next]
+	if h.full {
+		ordered = append(h.samples[h.next:len(h.samples):len(h.samples)], ordered...)
+	}
+	var samples []*execdriver.ResourceStats
+	for _, stats := range ordered {
+		if stats.Read.After(t) {
+			samples = append(samples, stats)
+		}
+	}
+	return samples
 }
 
 // collect registers the container with the collector and adds it to
@@ -59,9 +104,39 @@ func (s *statsCollector) stopCollection(c *Container) {
 		publisher.Close()
 		delete(s.publishers, c)
 	}
+	delete(s.last, c)
+	delete(s.histories, c)
 	s.m.Unlock()
 }
 
+// track keeps the history of the stats of c, which is collected whenever c
+// is running.
+func (s *statsCollector) track(c *Container) {
+	s.m.Lock()
+	if _, exists := s.histories[c]; !exists {
+		s.histories[c] = &statsHistory{samples: make([]*execdriver.ResourceStats, s.historySize)}
+	}
+	s.m.Unlock()
+}
+
+// setExporter makes the collector pass the stats it collects to e.
+func (s *statsCollector) setExporter(e *metricsExporter) {
+	s.m.Lock()
+	s.exporter = e
+	s.m.Unlock()
+}
+
+// history returns the stats of c read after since, oldest first.
+func (s *statsCollector) history(c *Container, since time.Time) []*execdriver.ResourceStats {
+	s.m.Lock()
+	defer s.m.Unlock()
+	h, exists := s.histories[c]
+	if !exists {
+		return nil
+	}
+	return h.since(since)
+}
+
 // unsubscribe removes a specific subscriber from receiving updates for a container's stats.
 func (s *statsCollector) unsubscribe(c *Container, ch chan interface{}) {
 	s.m.Lock()
@@ -75,9 +150,43 @@ func (s *statsCollector) unsubscribe(c *Container, ch chan interface{}) {
 	s.m.Unlock()
 }
 
+// latest returns the latest stats of c. They are collected now if c has no
+// subscriber or its stats are older than two collection intervals.
+func (s *statsCollector) latest(c *Container) (*execdriver.ResourceStats, error) {
+	s.m.Lock()
+	stats, exists := s.last[c]
+	s.m.Unlock()
+	if exists && time.Since(stats.Read) < 2*s.interval {
+		return stats, nil
+	}
+	stats, err := c.Stats()
+	if err != nil {
+		return nil, err
+	}
+	s.m.Lock()
+	s.last[c] = stats
+	s.m.Unlock()
+	return stats, nil
+}
+
 func (s *statsCollector) run() {
 	for _ = range time.Tick(s.interval) {
+		s.m.Lock()
+		containers := make(map[*Container]*pubsub.Publisher, len(s.publishers)+len(s.histories))
 		for container, publisher := range s.publishers {
+			containers[container] = publisher
+		}
+		exporter := s.exporter
+		if s.historySize > 0 || exporter != nil {
+			for container := range s.histories {
+				if _, exists := containers[container]; !exists && container.IsRunning() {
+					containers[container] = nil
+				}
+			}
+		}
+		s.m.Unlock()
+		collected := make(map[*Container]*execdriver.ResourceStats, len(containers))
+		for container, publisher := range containers {
 			systemUsage, err := s.getSystemCpuUsage()
 			if err != nil {
 				log.Errorf("collecting system cpu usage for %s: %v", container.ID, err)
@@ -91,7 +200,19 @@ func (s *statsCollector) run() {
 				continue
 			}
 			stats.SystemUsage = systemUsage
-			publisher.Publish(stats)
+			s.m.Lock()
+			s.last[container] = stats
+			if h, exists := s.histories[container]; exists {
+				h.add(stats)
+			}
+			s.m.Unlock()
+			collected[container] = stats
+			if publisher != nil {
+				publisher.Publish(stats)
+			}
+		}
+		if exporter != nil {
+			exporter.collected(collected, time.Now())
 		}
 	}
 }
@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+func TestStatsHistory(t *testing.T) {
+	h := &statsHistory{samples: make([]*execdriver.ResourceStats, 3)}
+	start := time.Unix(1000, 0)
+	reads := func(samples []*execdriver.ResourceStats) []int64 {
+		var r []int64
+		for _, s := range samples {
+			r = append(r, s.Read.Unix())
+		}
+		return r
+	}
+
+	if samples := h.since(time.Time{}); len(samples) != 0 {
+		t.Fatalf("Expected no samples, got %v", reads(samples))
+	}
+	for i := 0; i < 5; i++ {
+		h.add(&execdriver.ResourceStats{Read: start.Add(time.Duration(i) * time.Second)})
+	}
+	if r := reads(h.since(time.Time{})); len(r) != 3 || r[0] != 1002 || r[1] != 1003 || r[2] != 1004 {
+		t.Fatalf("Expected the 3 latest samples oldest first, got %v", r)
+	}
+	if r := reads(h.since(start.Add(3 * time.Second))); len(r) != 1 || r[0] != 1004 {
+		t.Fatalf("Expected the samples after 1003, got %v", r)
+	}
+}
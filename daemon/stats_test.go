@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups"
+)
+
+func TestConvertResourceStats(t *testing.T) {
+	cs := cgroups.NewStats()
+	cs.MemoryStats.Usage = 4096
+	cs.MemoryStats.Failcnt = 2
+	cs.MemoryStats.Stats["rss"] = 1024
+	cs.MemoryStats.Stats["cache"] = 2048
+	cs.MemoryStats.Stats["swap"] = 512
+	cs.MemoryStats.Stats["mapped_file"] = 256
+	cs.CpuStats.ThrottlingData.ThrottledPeriods = 3
+	cs.CpuStats.ThrottlingData.ThrottledTime = 5000
+	cs.BlkioStats.IoServicedRecursive = []cgroups.BlkioStatEntry{{Major: 8, Minor: 0, Op: "Read", Value: 7}}
+
+	ss := convertResourceStats(&execdriver.ResourceStats{
+		Stats:       &libcontainer.Stats{CgroupStats: cs},
+		MemoryLimit: 8192,
+		SystemUsage: 100,
+		OomKills:    1,
+		Pids:        4,
+	})
+	mem := ss.MemoryStats
+	if mem.Usage != 4096 || mem.Limit != 8192 || mem.Failcnt != 2 {
+		t.Fatalf("Unexpected memory usage, limit or failcnt: %+v", mem)
+	}
+	if mem.Rss != 1024 || mem.Cache != 2048 || mem.Swap != 512 || mem.MappedFile != 256 {
+		t.Fatalf("Unexpected memory breakdown: %+v", mem)
+	}
+	if mem.OomKills != 1 {
+		t.Fatalf("Expected 1 oom kill, got %d", mem.OomKills)
+	}
+	if ss.PidsStats.Current != 4 {
+		t.Fatalf("Expected 4 processes, got %d", ss.PidsStats.Current)
+	}
+	if td := ss.CpuStats.ThrottlingData; td.ThrottledPeriods != 3 || td.ThrottledTime != 5000 {
+		t.Fatalf("Unexpected throttling data: %+v", td)
+	}
+	if ss.CpuStats.SystemUsage != 100 {
+		t.Fatalf("Expected system usage 100, got %d", ss.CpuStats.SystemUsage)
+	}
+	if e := ss.BlkioStats.IoServicedRecursive; len(e) != 1 || e[0].Major != 8 || e[0].Op != "Read" || e[0].Value != 7 {
+		t.Fatalf("Unexpected blkio stats: %+v", e)
+	}
+}
+
+func TestConvertResourceStatsPidsCgroup(t *testing.T) {
+	cs := cgroups.NewStats()
+	cs.PidsStats.Current = 12
+	cs.PidsStats.Limit = 100
+
+	ss := convertResourceStats(&execdriver.ResourceStats{
+		Stats: &libcontainer.Stats{CgroupStats: cs},
+		Pids:  4,
+	})
+	if ss.PidsStats.Current != 12 || ss.PidsStats.Limit != 100 {
+		t.Fatalf("Expected the tasks of the pids cgroup, got %+v", ss.PidsStats)
+	}
+}
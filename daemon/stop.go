@@ -22,7 +22,9 @@ func (daemon *Daemon) ContainerStop(job *engine.Job) engine.Status {
 	if !container.IsRunning() {
 		return job.Errorf("Container already stopped")
 	}
+	container.setManuallyStopped(true)
 	if err := container.Stop(int(t)); err != nil {
+		container.setManuallyStopped(false)
 		return job.Errorf("Cannot stop container %s: %s\n", name, err)
 	}
 	container.LogEvent("stop")
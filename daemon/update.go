@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/engine"
+)
+
+// resourceUpdate holds the resources given to docker update, the zero values
+// are left unchanged. The swap of containers is always unlimited, so
+// MemorySwap can only be -1.
+type resourceUpdate struct {
+	Memory     int64
+	MemorySwap int64
+	CpuShares  int64
+	CpusetCpus string
+}
+
+// ContainerUpdate changes the resources of a container. They are written to
+// the cgroups of a running container, and saved to its hostconfig.json.
+func (daemon *Daemon) ContainerUpdate(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	container, err := daemon.Get(name)
+	if err != nil {
+		return job.Error(err)
+	}
+	update := &resourceUpdate{
+		Memory:     job.GetenvInt64("Memory"),
+		MemorySwap: job.GetenvInt64("MemorySwap"),
+		CpuShares:  job.GetenvInt64("CpuShares"),
+		CpusetCpus: job.Getenv("CpusetCpus"),
+	}
+	if err := daemon.update(container, update); err != nil {
+		return job.Errorf("Cannot update container %s: %s", name, err)
+	}
+	container.LogEvent("update")
+	return engine.StatusOK
+}
+
+func (daemon *Daemon) update(container *Container, update *resourceUpdate) error {
+	container.Lock()
+	defer container.Unlock()
+
+	if container.removalInProgress || container.Dead {
+		return fmt.Errorf("Container is marked for removal and cannot be updated")
+	}
+
+	hostConfig := *container.hostConfig
+	if update.Memory != 0 {
+		hostConfig.Memory = update.Memory
+	}
+	if update.MemorySwap != 0 && update.MemorySwap != -1 {
+		return fmt.Errorf("Swap limit is not supported, the swap of containers is unlimited")
+	}
+	if update.CpuShares != 0 {
+		hostConfig.CpuShares = update.CpuShares
+	}
+	if update.CpusetCpus != "" {
+		hostConfig.CpusetCpus = update.CpusetCpus
+	}
+	if err := daemon.verifyResources(&hostConfig); err != nil {
+		return err
+	}
+
+	// the command is kept across restarts by the restart policy, so it's
+	// updated even when the process isn't running
+	if container.command != nil && container.command.Resources != nil {
+		old := container.command.Resources
+		resources := *old
+		resources.Memory = hostConfig.Memory
+		resources.MemorySwap = hostConfig.MemorySwap
+		resources.CpuShares = hostConfig.CpuShares
+		resources.CpusetCpus = hostConfig.CpusetCpus
+		container.command.Resources = &resources
+		if container.Running && !container.Restarting {
+			if err := daemon.execDriver.Update(container.command); err != nil {
+				container.command.Resources = old
+				return err
+			}
+		}
+	}
+
+	*container.hostConfig = hostConfig
+	return container.WriteHostConfig()
+}
@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/sysinfo"
+	"github.com/docker/docker/runconfig"
+)
+
+func TestUpdateStoppedContainer(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-update-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	daemon := &Daemon{sysInfo: &sysinfo.SysInfo{MemoryLimit: true, SwapLimit: true, Cpus: "0-3"}}
+	container := &Container{
+		ID:         "8bc4a3dbe0a2",
+		root:       root,
+		State:      NewState(),
+		hostConfig: &runconfig.HostConfig{Memory: 64 << 20, MemorySwap: -1, CpuShares: 512},
+		command:    &execdriver.Command{Resources: &execdriver.Resources{Memory: 64 << 20, MemorySwap: -1, CpuShares: 512}},
+	}
+
+	// the swap of containers is unlimited
+	if err := daemon.update(container, &resourceUpdate{Memory: 128 << 20, MemorySwap: -1, CpusetCpus: "0,1"}); err != nil {
+		t.Fatal(err)
+	}
+	if hc := container.hostConfig; hc.Memory != 128<<20 || hc.MemorySwap != -1 || hc.CpuShares != 512 || hc.CpusetCpus != "0,1" {
+		t.Fatalf("Unexpected host config %+v", hc)
+	}
+	if r := container.command.Resources; r.Memory != 128<<20 || r.MemorySwap != -1 || r.CpuShares != 512 || r.CpusetCpus != "0,1" {
+		t.Fatalf("Unexpected resources %+v", r)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(root, "hostconfig.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved runconfig.HostConfig
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Memory != 128<<20 || saved.CpusetCpus != "0,1" {
+		t.Fatalf("Unexpected saved host config %+v", saved)
+	}
+
+	for _, update := range []*resourceUpdate{
+		{Memory: 1024},
+		{MemorySwap: 32 << 20},
+		{CpuShares: -1},
+		{CpusetCpus: "0-"},
+		{CpusetCpus: "1,4"},
+	} {
+		if err := daemon.update(container, update); err == nil {
+			t.Fatalf("Expected an error for %+v", update)
+		}
+	}
+	if hc := container.hostConfig; hc.Memory != 128<<20 || hc.MemorySwap != -1 || hc.CpusetCpus != "0,1" {
+		t.Fatalf("Expected the host config to be unchanged, got %+v", container.hostConfig)
+	}
+}
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/libcontainer/user"
+)
+
+const (
+	// defaultRemappedID is the user and group whose subordinate ids are
+	// used with --userns-remap=default
+	defaultRemappedID = "dockremap"
+)
+
+// parseRemappedRoot returns the user and group names of the
+// --userns-remap=USER[:GROUP] setting, where both may also be given by id.
+// The group defaults to the user's name.
+func parseRemappedRoot(usergrp string) (string, string, error) {
+	if usergrp == "default" {
+		return defaultRemappedID, defaultRemappedID, nil
+	}
+
+	var userID int
+	parts := strings.SplitN(usergrp, ":", 2)
+
+	username := parts[0]
+	if uid, err := strconv.ParseInt(username, 10, 32); err == nil {
+		// the subordinate id files are keyed by name
+		u, err := user.LookupUid(int(uid))
+		if err != nil {
+			return "", "", fmt.Errorf("Uid %d has no entry in /etc/passwd: %v", uid, err)
+		}
+		username, userID = u.Name, u.Uid
+	} else {
+		u, err := user.LookupUser(username)
+		if err != nil {
+			return "", "", fmt.Errorf("User %q has no entry in /etc/passwd: %v", username, err)
+		}
+		userID = u.Uid
+	}
+
+	groupname := username
+	if len(parts) == 2 {
+		groupname = parts[1]
+		if gid, err := strconv.ParseInt(groupname, 10, 32); err == nil {
+			g, err := user.LookupGid(int(gid))
+			if err != nil {
+				return "", "", fmt.Errorf("Gid %d has no entry in /etc/group: %v", gid, err)
+			}
+			groupname = g.Name
+		} else if _, err := user.LookupGroup(groupname); err != nil {
+			return "", "", fmt.Errorf("Group %q has no entry in /etc/group: %v", groupname, err)
+		}
+	}
+
+	if userID == 0 {
+		return "", "", fmt.Errorf("Cannot remap the root of user namespaces to the root user")
+	}
+	return username, groupname, nil
+}
+
+// setupRemappedRoot returns the uid and gid mappings of the user namespace
+// of the containers, which are nil when --userns-remap is not set
+func setupRemappedRoot(config *Config) ([]idtools.IDMap, []idtools.IDMap, error) {
+	if config.RemappedRoot == "" {
+		return nil, nil, nil
+	}
+	if config.ExecDriver != "native" {
+		return nil, nil, fmt.Errorf("User namespaces are only supported by the native exec driver")
+	}
+
+	username, groupname, err := parseRemappedRoot(config.RemappedRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	return idtools.CreateIDMappings(username, groupname)
+}
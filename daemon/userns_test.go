@@ -0,0 +1,20 @@
+package daemon
+
+import "testing"
+
+func TestParseRemappedRoot(t *testing.T) {
+	if user, group, err := parseRemappedRoot("default"); err != nil || user != defaultRemappedID || group != defaultRemappedID {
+		t.Fatalf("Expected %q for the default remapped root, got %q:%q (%v)", defaultRemappedID, user, group, err)
+	}
+
+	// the root user of the host can't be the root of the containers
+	for _, usergrp := range []string{"root", "0", "root:root", "0:0"} {
+		if _, _, err := parseRemappedRoot(usergrp); err == nil {
+			t.Fatalf("Expected %q to be rejected as remapped root", usergrp)
+		}
+	}
+
+	if _, _, err := parseRemappedRoot("nonexistent-docker-user"); err == nil {
+		t.Fatal("Expected an unknown user to be rejected as remapped root")
+	}
+}
@@ -113,6 +113,7 @@ func init() {
 			{"tag", "Tag an image into a repository"},
 			{"top", "Lookup the running processes of a container"},
 			{"unpause", "Unpause a paused container"},
+			{"update", "Update the resources of one or more containers"},
 			{"version", "Show the Docker version information"},
 			{"wait", "Block until a container stops, then print its exit code"},
 			{"registerip", "Register a fixed ip"},
@@ -8,24 +8,27 @@ import (
 	"sync"
 	"time"
 
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/parsers/filters"
-	"github.com/docker/docker/utils"
 )
 
 const eventsLimit = 64
 
-type listener chan<- *utils.JSONMessage
+type listener chan<- *types.Event
 
 type Events struct {
 	mu          sync.RWMutex
-	events      []*utils.JSONMessage
+	events      []*types.Event
 	subscribers []listener
+	// journal is nil until the daemon opens it with the events_journal job
+	journal *journal
 }
 
 func New() *Events {
 	return &Events{
-		events: make([]*utils.JSONMessage, 0, eventsLimit),
+		events: make([]*types.Event, 0, eventsLimit),
 	}
 }
 
@@ -34,6 +37,8 @@ func (e *Events) Install(eng *engine.Engine) error {
 	// Here you should describe public interface
 	jobs := map[string]engine.Handler{
 		"events":            e.Get,
+		"events_journal":    e.OpenJournal,
+		"events_webhook":    e.AddWebhook,
 		"log":               e.Log,
 		"subscribers_count": e.SubscribersCount,
 	}
@@ -49,6 +54,7 @@ func (e *Events) Get(job *engine.Job) engine.Status {
 	var (
 		since   = job.GetenvInt64("since")
 		until   = job.GetenvInt64("until")
+		legacy  = job.GetenvBool("legacy")
 		timeout = time.NewTimer(time.Unix(until, 0).Sub(time.Now()))
 	)
 
@@ -56,21 +62,41 @@ func (e *Events) Get(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
+	// incoming container filter can be name, id or partial id: resolve each
+	// of them once to a full container id, keeping the given value to match
+	// containers by name
+	for _, cn := range eventFilters["container"] {
+		if id := GetContainerId(job.Eng, cn); id != "" && id != cn {
+			eventFilters["container"] = append(eventFilters["container"], id)
+		}
+	}
 
 	// If no until, disable timeout
 	if until == 0 {
 		timeout.Stop()
 	}
 
-	listener := make(chan *utils.JSONMessage)
-	e.subscribe(listener)
-	defer e.unsubscribe(listener)
-
+	listener := make(chan *types.Event)
 	job.Stdout.Write(nil)
 
-	// Resend every event in the [since, until] time interval.
+	// The past events are taken and the listener subscribes under the same
+	// lock, so no event logged meanwhile is missed or sent twice. The past
+	// events are resent without holding the lock, which would keep a slow
+	// client from holding back the events of the other subscribers.
+	var current eventSnapshot
+	e.mu.Lock()
 	if since != 0 {
-		if err := e.writeCurrent(job, since, until, eventFilters); err != nil {
+		if current, err = e.snapshot(); err != nil {
+			e.mu.Unlock()
+			return job.Error(err)
+		}
+	}
+	e.subscribers = append(e.subscribers, listener)
+	e.mu.Unlock()
+	defer e.unsubscribe(listener)
+
+	if current != nil {
+		if err := writeCurrent(job, current, since, until, listener, eventFilters, legacy); err != nil {
 			return job.Error(err)
 		}
 	}
@@ -81,7 +107,7 @@ func (e *Events) Get(job *engine.Job) engine.Status {
 			if !ok {
 				return engine.StatusOK
 			}
-			if err := writeEvent(job, event, eventFilters); err != nil {
+			if err := writeEvent(job, event, eventFilters, legacy); err != nil {
 				return job.Error(err)
 			}
 		case <-timeout.C:
@@ -90,12 +116,55 @@ func (e *Events) Get(job *engine.Job) engine.Status {
 	}
 }
 
+// Log publishes an event. Arguments are the action, the actor ID and, for
+// container events, the image of the container. The optional Type env is
+// the type of the actor (container by default), the Attributes env holds
+// a JSON encoded map of event details and the Labels env the JSON encoded
+// labels of the actor.
 func (e *Events) Log(job *engine.Job) engine.Status {
 	if len(job.Args) != 3 {
 		return job.Errorf("usage: %s ACTION ID FROM", job.Name)
 	}
+	var attributes, labels map[string]string
+	if err := job.GetenvJson("Attributes", &attributes); err != nil {
+		return job.Error(err)
+	}
+	if err := job.GetenvJson("Labels", &labels); err != nil {
+		return job.Error(err)
+	}
+	eventType := job.Getenv("Type")
+	if eventType == "" {
+		eventType = types.ContainerEventType
+	}
 	// not waiting for receivers
-	go e.log(job.Args[0], job.Args[1], job.Args[2])
+	go e.log(eventType, job.Args[0], job.Args[1], job.Args[2], attributes, labels)
+	return engine.StatusOK
+}
+
+// OpenJournal starts persisting events to an on-disk journal in the directory
+// given as argument, so "since" queries can be answered across daemon
+// restarts. Journal size is capped by the MaxSize env in bytes.
+func (e *Events) OpenJournal(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("usage: %s DIR", job.Name)
+	}
+	j, err := openJournal(job.Args[0], job.GetenvInt64("MaxSize"))
+	if err != nil {
+		return job.Error(err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.journal != nil {
+		j.close()
+		return job.Errorf("events journal is already open")
+	}
+	// events logged before the journal was opened are only in memory
+	for _, jm := range e.events {
+		if err := j.append(jm); err != nil {
+			log.Errorf("Error writing event to journal: %s", err)
+		}
+	}
+	e.journal = j
 	return engine.StatusOK
 }
 
@@ -106,7 +175,8 @@ func (e *Events) SubscribersCount(job *engine.Job) engine.Status {
 	return engine.StatusOK
 }
 
-func writeEvent(job *engine.Job, event *utils.JSONMessage, eventFilters filters.Args) error {
+// matchEvent reports whether event passes eventFilters
+func matchEvent(event *types.Event, eventFilters filters.Args) bool {
 	isFiltered := func(field string, filter []string) bool {
 		if len(filter) == 0 {
 			return false
@@ -125,19 +195,49 @@ func writeEvent(job *engine.Job, event *utils.JSONMessage, eventFilters filters.
 		return true
 	}
 
-	//incoming container filter can be name,id or partial id, convert and replace as a full container id
-	for i, cn := range eventFilters["container"] {
-		eventFilters["container"][i] = GetContainerId(job.Eng, cn)
+	// container and image filters apply to the actor of events of their type
+	var containerID, containerName, image string
+	switch event.Type {
+	case types.ContainerEventType:
+		containerID, containerName, image = event.Actor.ID, event.Actor.Attributes["name"], event.From
+	case types.ImageEventType:
+		image = event.Actor.ID
+	}
+
+	if isFiltered(event.Type, eventFilters["type"]) || isFiltered(event.Action, eventFilters["action"]) ||
+		isFiltered(event.Status, eventFilters["event"]) || isFiltered(image, eventFilters["image"]) ||
+		(isFiltered(containerID, eventFilters["container"]) && isFiltered(containerName, eventFilters["container"])) {
+		return false
 	}
+	return eventFilters.MatchKVList("label", event.Actor.Labels)
+}
 
-	if isFiltered(event.Status, eventFilters["event"]) || isFiltered(event.From, eventFilters["image"]) ||
-		isFiltered(event.ID, eventFilters["container"]) {
+// legacyEvent is an event in the form known by the clients of API versions
+// older than 1.18
+type legacyEvent struct {
+	Status string `json:"status,omitempty"`
+	ID     string `json:"id,omitempty"`
+	From   string `json:"from,omitempty"`
+	Time   int64  `json:"time,omitempty"`
+}
+
+// writeEvent writes event if it passes eventFilters. With legacy, only the
+// container and image events are written, in the legacy form.
+func writeEvent(job *engine.Job, event *types.Event, eventFilters filters.Args, legacy bool) error {
+	if !matchEvent(event, eventFilters) {
 		return nil
 	}
+	var v interface{} = event
+	if legacy {
+		if event.Type != types.ContainerEventType && event.Type != types.ImageEventType {
+			return nil
+		}
+		v = &legacyEvent{Status: event.Status, ID: event.ID, From: event.From, Time: event.Time}
+	}
 
 	// When sending an event JSON serialization errors are ignored, but all
 	// other errors lead to the eviction of the listener.
-	if b, err := json.Marshal(event); err == nil {
+	if b, err := json.Marshal(v); err == nil {
 		if _, err = job.Stdout.Write(b); err != nil {
 			return err
 		}
@@ -145,18 +245,65 @@ func writeEvent(job *engine.Job, event *utils.JSONMessage, eventFilters filters.
 	return nil
 }
 
-func (e *Events) writeCurrent(job *engine.Job, since, until int64, eventFilters filters.Args) error {
-	e.mu.RLock()
-	for _, event := range e.events {
-		if event.Time >= since && (event.Time <= until || until == 0) {
-			if err := writeEvent(job, event, eventFilters); err != nil {
-				e.mu.RUnlock()
+// eventSnapshot calls fn for the events of a snapshot of the past events in
+// the [since, until] time interval, and releases the snapshot
+type eventSnapshot func(since, until int64, fn func(*types.Event) error) error
+
+// snapshot takes a snapshot of the past events, from the journal when it is
+// open. It must be called with e.mu held, so the snapshot ends with the last
+// event sent to the subscribers.
+func (e *Events) snapshot() (eventSnapshot, error) {
+	if e.journal != nil {
+		s, err := e.journal.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		return func(since, until int64, fn func(*types.Event) error) error {
+			defer s.close()
+			return s.read(since, until, fn)
+		}, nil
+	}
+	events := make([]*types.Event, len(e.events))
+	copy(events, e.events)
+	return func(since, until int64, fn func(*types.Event) error) error {
+		for _, event := range events {
+			if event.Time >= since && (event.Time <= until || until == 0) {
+				if err := fn(event); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, nil
+}
+
+// writeCurrent writes the past events of current in the [since, until] time
+// interval. The events received meanwhile by listener are queued, and written
+// after them.
+func writeCurrent(job *engine.Job, current eventSnapshot, since, until int64, listener <-chan *types.Event, eventFilters filters.Args, legacy bool) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- current(since, until, func(event *types.Event) error {
+			return writeEvent(job, event, eventFilters, legacy)
+		})
+	}()
+	var queued []*types.Event
+	for {
+		select {
+		case event := <-listener:
+			queued = append(queued, event)
+		case err := <-done:
+			if err != nil {
 				return err
 			}
+			for _, event := range queued {
+				if err := writeEvent(job, event, eventFilters, legacy); err != nil {
+					return err
+				}
+			}
+			return nil
 		}
 	}
-	e.mu.RUnlock()
-	return nil
 }
 
 func (e *Events) subscribersCount() int {
@@ -166,10 +313,22 @@ func (e *Events) subscribersCount() int {
 	return c
 }
 
-func (e *Events) log(action, id, from string) {
+func (e *Events) log(eventType, action, id, from string, attributes, labels map[string]string) {
 	e.mu.Lock()
 	now := time.Now().UTC().Unix()
-	jm := &utils.JSONMessage{Status: action, ID: id, From: from, Time: now}
+	jm := &types.Event{
+		Status: action,
+		ID:     id,
+		From:   from,
+		Type:   eventType,
+		Action: action,
+		Actor: types.EventActor{
+			ID:         id,
+			Attributes: attributes,
+			Labels:     labels,
+		},
+		Time: now,
+	}
 	if len(e.events) == cap(e.events) {
 		// discard oldest event
 		copy(e.events, e.events[1:])
@@ -177,6 +336,11 @@ func (e *Events) log(action, id, from string) {
 	} else {
 		e.events = append(e.events, jm)
 	}
+	if e.journal != nil {
+		if err := e.journal.append(jm); err != nil {
+			log.Errorf("Error writing event to journal: %s", err)
+		}
+	}
 	for _, s := range e.subscribers {
 		// We give each subscriber a 100ms time window to receive the event,
 		// after which we move to the next.
@@ -5,24 +5,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/utils"
 )
 
 func TestEventsPublish(t *testing.T) {
 	e := New()
-	l1 := make(chan *utils.JSONMessage)
-	l2 := make(chan *utils.JSONMessage)
+	l1 := make(chan *types.Event)
+	l2 := make(chan *types.Event)
 	e.subscribe(l1)
 	e.subscribe(l2)
 	count := e.subscribersCount()
 	if count != 2 {
 		t.Fatalf("Must be 2 subscribers, got %d", count)
 	}
-	go e.log("test", "cont", "image")
+	go e.log(types.ContainerEventType, "test", "cont", "image", nil, nil)
 	select {
 	case msg := <-l1:
 		if len(e.events) != 1 {
@@ -61,12 +63,12 @@ func TestEventsPublish(t *testing.T) {
 
 func TestEventsPublishTimeout(t *testing.T) {
 	e := New()
-	l := make(chan *utils.JSONMessage)
+	l := make(chan *types.Event)
 	e.subscribe(l)
 
 	c := make(chan struct{})
 	go func() {
-		e.log("test", "cont", "image")
+		e.log(types.ContainerEventType, "test", "cont", "image", nil, nil)
 		close(c)
 	}()
 
@@ -138,8 +140,8 @@ func TestEventsCountJob(t *testing.T) {
 	if err := e.Install(eng); err != nil {
 		t.Fatal(err)
 	}
-	l1 := make(chan *utils.JSONMessage)
-	l2 := make(chan *utils.JSONMessage)
+	l1 := make(chan *types.Event)
+	l2 := make(chan *types.Event)
 	e.subscribe(l1)
 	e.subscribe(l2)
 	job := eng.Job("subscribers_count")
@@ -152,3 +154,138 @@ func TestEventsCountJob(t *testing.T) {
 		t.Fatalf("There must be 2 subscribers, got %d", count)
 	}
 }
+
+func TestEventsTypeFilter(t *testing.T) {
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	e.log(types.ContainerEventType, "die", "cont_1", "image_1", map[string]string{"exitCode": "137", "name": "web1"}, nil)
+	e.log(types.ImageEventType, "untag", "image_1", "", map[string]string{"name": "busybox:latest"}, nil)
+
+	job := eng.Job("events")
+	job.SetenvInt64("since", 1)
+	job.SetenvInt64("until", time.Now().Unix())
+	job.Setenv("filters", `{"type":["container"],"action":["die"]}`)
+	buf := bytes.NewBuffer(nil)
+	job.Stdout.Add(buf)
+	if err := job.Run(); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewBuffer(buf.Bytes()))
+	var msgs []types.Event
+	for {
+		var ev types.Event
+		if err := dec.Decode(&ev); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		msgs = append(msgs, ev)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Must be 1 event, got %d", len(msgs))
+	}
+	ev := msgs[0]
+	if ev.Type != "container" || ev.Action != "die" || ev.Actor.ID != "cont_1" || ev.Actor.Attributes["exitCode"] != "137" {
+		t.Fatalf("Wrong event: %+v", ev)
+	}
+	// legacy fields are kept for old clients
+	if ev.Status != "die" || ev.ID != "cont_1" || ev.From != "image_1" {
+		t.Fatalf("Wrong legacy fields: %+v", ev)
+	}
+}
+
+func TestEventsLabelFilter(t *testing.T) {
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	e.log(types.ContainerEventType, "start", "cont_1", "image_1", map[string]string{"name": "web1"}, map[string]string{"tier": "front"})
+	e.log(types.ContainerEventType, "die", "cont_2", "image_1", map[string]string{"name": "db1", "exitCode": "0"}, map[string]string{"tier": "back", "exitCode": "1"})
+	e.log(types.ImageEventType, "pull", "image_1", "", nil, map[string]string{"tier": "front"})
+
+	for filter, expected := range map[string][]string{
+		`{"label":["tier=front"]}`:                       {"cont_1", "image_1"},
+		`{"label":["tier"]}`:                             {"cont_1", "cont_2", "image_1"},
+		`{"label":["tier=front"],"type":["container"]}`:  {"cont_1"},
+		`{"container":["db1"]}`:                          {"cont_2"},
+		`{"container":["cont_1"],"label":["tier=back"]}`: nil,
+		// labels are only matched against labels, even if an attribute
+		// has the same key
+		`{"label":["name=web1"]}`:  nil,
+		`{"label":["exitCode=0"]}`: nil,
+		`{"label":["exitCode=1"]}`: {"cont_2"},
+	} {
+		job := eng.Job("events")
+		job.SetenvInt64("since", 1)
+		job.SetenvInt64("until", time.Now().Unix())
+		job.Setenv("filters", filter)
+		buf := bytes.NewBuffer(nil)
+		job.Stdout.Add(buf)
+		if err := job.Run(); err != nil {
+			t.Fatal(err)
+		}
+		dec := json.NewDecoder(bytes.NewBuffer(buf.Bytes()))
+		var ids []string
+		for {
+			var ev types.Event
+			if err := dec.Decode(&ev); err != nil {
+				if err == io.EOF {
+					break
+				}
+				t.Fatal(err)
+			}
+			ids = append(ids, ev.Actor.ID)
+		}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Fatalf("Filter %s: expected events of %v, got %v", filter, expected, ids)
+		}
+	}
+}
+
+func TestEventsLegacy(t *testing.T) {
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	e.log(types.ContainerEventType, "die", "cont_1", "image_1", map[string]string{"exitCode": "137"}, nil)
+	e.log(types.NetworkEventType, "connect", "net_1", "", map[string]string{"container": "cont_1"}, nil)
+	e.log(types.ImageEventType, "untag", "image_1", "", nil, nil)
+
+	job := eng.Job("events")
+	job.SetenvInt64("since", 1)
+	job.SetenvInt64("until", time.Now().Unix())
+	job.SetenvBool("legacy", true)
+	buf := bytes.NewBuffer(nil)
+	job.Stdout.Add(buf)
+	if err := job.Run(); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewBuffer(buf.Bytes()))
+	var msgs []map[string]interface{}
+	for {
+		var ev map[string]interface{}
+		if err := dec.Decode(&ev); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		msgs = append(msgs, ev)
+	}
+	if len(msgs) != 2 || msgs[0]["status"] != "die" || msgs[0]["from"] != "image_1" || msgs[1]["status"] != "untag" {
+		t.Fatalf("Wrong legacy events: %v", msgs)
+	}
+	for _, ev := range msgs {
+		for _, field := range []string{"Type", "Action", "Actor"} {
+			if _, ok := ev[field]; ok {
+				t.Fatalf("Legacy event %v has a %s field", ev, field)
+			}
+		}
+	}
+}
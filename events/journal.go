@@ -0,0 +1,187 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+)
+
+const (
+	journalFile = "events.log"
+	// DefaultJournalMaxSize is the default size cap of the events journal
+	DefaultJournalMaxSize = 16 * 1024 * 1024
+)
+
+// journal is an append-only on-disk log of events, one JSON message per
+// line. It is kept under maxSize by rotating the current file to a single
+// backup once the current file grows over half of maxSize.
+type journal struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+func openJournal(dir string, maxSize int64) (*journal, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultJournalMaxSize
+	}
+	j := &journal{
+		path:    filepath.Join(dir, journalFile),
+		maxSize: maxSize,
+	}
+	if err := j.openCurrent(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *journal) openCurrent() error {
+	f, err := os.OpenFile(j.path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	j.f = f
+	j.size = fi.Size()
+	return nil
+}
+
+// rotate moves the current file to the backup, replacing the previous backup
+func (j *journal) rotate() error {
+	if err := j.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(j.path, j.path+".1"); err != nil {
+		return err
+	}
+	return j.openCurrent()
+}
+
+func (j *journal) append(jm *types.Event) error {
+	b, err := json.Marshal(jm)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.size > 0 && j.size+int64(len(b)) > j.maxSize/2 {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.f.Write(b)
+	j.size += int64(n)
+	return err
+}
+
+// read calls fn for every journaled event in the [since, until] time
+// interval, oldest first. until equal to 0 means no upper bound.
+func (j *journal) read(since, until int64, fn func(*types.Event) error) error {
+	s, err := j.snapshot()
+	if err != nil {
+		return err
+	}
+	defer s.close()
+	return s.read(since, until, fn)
+}
+
+// journalSnapshot holds the journal files as they were when it was taken
+type journalSnapshot struct {
+	files []*os.File
+	// sizes are the sizes of the files to read, -1 for the whole file
+	sizes []int64
+}
+
+// snapshot opens the journal files under lock, so a concurrent rotation can't
+// make the reads of the snapshot skip or repeat events. The snapshot is read
+// without holding the lock, and must be closed once read.
+func (j *journal) snapshot() (*journalSnapshot, error) {
+	s := &journalSnapshot{}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, pth := range []string{j.path + ".1", j.path} {
+		f, err := os.Open(pth)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			s.close()
+			return nil, err
+		}
+		s.files = append(s.files, f)
+		if pth == j.path {
+			s.sizes = append(s.sizes, j.size)
+		} else {
+			s.sizes = append(s.sizes, -1)
+		}
+	}
+	return s, nil
+}
+
+// read calls fn for every event of the snapshot in the [since, until] time
+// interval, oldest first. until equal to 0 means no upper bound.
+func (s *journalSnapshot) read(since, until int64, fn func(*types.Event) error) error {
+	for i, f := range s.files {
+		var r io.Reader = f
+		if s.sizes[i] >= 0 {
+			r = io.LimitReader(f, s.sizes[i])
+		}
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			jm := &types.Event{}
+			if err := json.Unmarshal(scanner.Bytes(), jm); err != nil {
+				// a torn write after a crash, skip it
+				log.Debugf("Skipping malformed events journal entry %q: %s", scanner.Bytes(), err)
+				continue
+			}
+			if jm.Action == "" {
+				// journaled before events had a type, action and actor
+				jm.Action, jm.Actor.ID = jm.Status, jm.ID
+			}
+			if jm.Time < since || (until != 0 && jm.Time > until) {
+				continue
+			}
+			if err := fn(jm); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *journalSnapshot) close() {
+	for _, f := range s.files {
+		f.Close()
+	}
+}
+
+// dir returns the directory holding the journal files
+func (j *journal) dir() string {
+	return filepath.Dir(j.path)
+}
+
+func (j *journal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.f.Close()
+}
@@ -0,0 +1,272 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/utils"
+)
+
+func TestJournalRotate(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := openJournal(tmp, 16384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.close()
+	for i := 0; i < 200; i++ {
+		jm := &types.Event{Status: fmt.Sprintf("action_%d", i), ID: "cont", From: "image", Time: int64(i)}
+		if err := j.append(jm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var total int64
+	for _, name := range []string{journalFile, journalFile + ".1"} {
+		fi, err := os.Stat(tmp + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += fi.Size()
+	}
+	if total > 16384 {
+		t.Fatalf("Journal must be capped at 16384 bytes, got %d", total)
+	}
+
+	var got []int64
+	if err := j.read(150, 0, func(jm *types.Event) error {
+		got = append(got, jm.Time)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 50 || got[0] != 150 || got[49] != 199 {
+		t.Fatalf("Wrong events read from journal: %v", got)
+	}
+}
+
+func TestJournalSurvivesRestart(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Job("events_journal", tmp).Run(); err != nil {
+		t.Fatal(err)
+	}
+	e.log(types.ContainerEventType, "die", "cont_1", "image_1", nil, nil)
+	e.log(types.ContainerEventType, "destroy", "cont_1", "image_1", nil, nil)
+	e.journal.close()
+
+	// a new instance has no events in memory, but still knows the history
+	e = New()
+	eng = engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Job("events_journal", tmp).Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.journal.close()
+
+	job := eng.Job("events")
+	job.SetenvInt64("since", 1)
+	job.SetenvInt64("until", time.Now().Unix())
+	buf := bytes.NewBuffer(nil)
+	job.Stdout.Add(buf)
+	if err := job.Run(); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewBuffer(buf.Bytes()))
+	var msgs []utils.JSONMessage
+	for {
+		var jm utils.JSONMessage
+		if err := dec.Decode(&jm); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		msgs = append(msgs, jm)
+	}
+	if len(msgs) != 2 || msgs[0].Status != "die" || msgs[1].Status != "destroy" {
+		t.Fatalf("Wrong events after restart: %v", msgs)
+	}
+}
+
+// slowWriter delays writes, and signals the first one on started
+type slowWriter struct {
+	bytes.Buffer
+	started chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		select {
+		case <-w.started:
+		default:
+			close(w.started)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestJournalReplayWhileLogging(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Job("events_journal", tmp).Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.journal.close()
+	for i := 0; i < 200; i++ {
+		e.log(types.ContainerEventType, "start", fmt.Sprintf("cont_%d", i), "image", nil, nil)
+	}
+
+	// events logged while the journal is replayed are sent once, after it
+	w := &slowWriter{started: make(chan struct{})}
+	job := eng.Job("events")
+	job.SetenvInt64("since", 1)
+	// until leaves the time to replay the journal and send the new events
+	job.SetenvInt64("until", time.Now().Unix()+3)
+	job.Stdout.Add(w)
+	done := make(chan error)
+	go func() {
+		done <- job.Run()
+	}()
+	<-w.started
+	for i := 200; i < 220; i++ {
+		e.log(types.ContainerEventType, "start", fmt.Sprintf("cont_%d", i), "image", nil, nil)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(bytes.NewBuffer(w.Bytes()))
+	seen := make(map[string]int)
+	for {
+		var jm utils.JSONMessage
+		if err := dec.Decode(&jm); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		seen[jm.ID]++
+	}
+	for i := 0; i < 220; i++ {
+		if n := seen[fmt.Sprintf("cont_%d", i)]; n != 1 {
+			t.Fatalf("Event of cont_%d sent %d times", i, n)
+		}
+	}
+}
+
+// blockingWriter blocks the writes from the first one until release is closed
+type blockingWriter struct {
+	bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		select {
+		case <-w.started:
+		default:
+			close(w.started)
+		}
+		<-w.release
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestJournalReplayToSlowClient(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Job("events_journal", tmp).Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.journal.close()
+	e.log(types.ContainerEventType, "start", "cont_0", "image", nil, nil)
+
+	w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+	job := eng.Job("events")
+	job.SetenvInt64("since", 1)
+	job.SetenvInt64("until", time.Now().Unix()+3)
+	job.Stdout.Add(w)
+	done := make(chan error)
+	go func() {
+		done <- job.Run()
+	}()
+	<-w.started
+
+	// the other subscribers get the events while the client is replayed to
+	l := make(chan *types.Event)
+	e.subscribe(l)
+	go e.log(types.ContainerEventType, "start", "cont_1", "image", nil, nil)
+	select {
+	case jm := <-l:
+		if jm.ID != "cont_1" {
+			t.Fatalf("Expected the event of cont_1, got %v", jm)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Logging an event blocked on the replay to a slow client")
+	}
+	e.unsubscribe(l)
+
+	close(w.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewBuffer(w.Bytes()))
+	var ids []string
+	for {
+		var jm utils.JSONMessage
+		if err := dec.Decode(&jm); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		ids = append(ids, jm.ID)
+	}
+	if len(ids) != 2 || ids[0] != "cont_0" || ids[1] != "cont_1" {
+		t.Fatalf("Expected the events of cont_0 and cont_1, got %v", ids)
+	}
+}
@@ -0,0 +1,355 @@
+package events
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+const (
+	webhookBatchSize     = 100
+	webhookQueueSize     = 10000
+	webhookFlushInterval = time.Second
+	webhookTimeout       = 10 * time.Second
+	webhookMinBackoff    = time.Second
+	webhookMaxBackoff    = time.Minute
+)
+
+var errWebhookStopped = errors.New("events webhook stopped")
+
+var webhookFilterKeys = map[string]bool{
+	"action":    true,
+	"container": true,
+	"event":     true,
+	"image":     true,
+	"label":     true,
+	"type":      true,
+}
+
+// ParseWebhook parses an events webhook specification of the form
+// URL[,KEY=VALUE...], where the KEY=VALUE pairs are events filters, e.g.
+// "http://collector:8080/events,type=container,label=env=prod". The URL
+// ends at the first comma followed by the key of a filter, so it can hold
+// commas itself.
+func ParseWebhook(spec string) (string, filters.Args, error) {
+	endpoint, rest := spec, ""
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != ',' {
+			continue
+		}
+		if kv := strings.SplitN(spec[i+1:], "=", 2); len(kv) == 2 && webhookFilterKeys[kv[0]] {
+			endpoint, rest = spec[:i], spec[i+1:]
+			break
+		}
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", nil, fmt.Errorf("Invalid events webhook URL %q, must be an http or https URL", endpoint)
+	}
+	webhookFilters := filters.Args{}
+	if rest == "" {
+		return endpoint, webhookFilters, nil
+	}
+	for _, f := range strings.Split(rest, ",") {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 || !webhookFilterKeys[kv[0]] {
+			return "", nil, fmt.Errorf("Invalid events webhook filter %q", f)
+		}
+		webhookFilters[kv[0]] = append(webhookFilters[kv[0]], kv[1])
+	}
+	return endpoint, webhookFilters, nil
+}
+
+// AddWebhook starts delivering events to the HTTP endpoint given as argument
+// in the URL[,KEY=VALUE...] form of ParseWebhook. Events are POSTed as JSON
+// arrays in batches, a batch is retried with exponential backoff until the
+// endpoint answers with a 2xx status. When the events journal is open, events
+// the endpoint missed while unreachable or while the daemon was down are read
+// back from the journal, so an endpoint may receive an event more than once.
+// Delivery stops when the engine shuts down.
+func (e *Events) AddWebhook(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("usage: %s URL[,KEY=VALUE...]", job.Name)
+	}
+	endpoint, webhookFilters, err := ParseWebhook(job.Args[0])
+	if err != nil {
+		return job.Error(err)
+	}
+	w, err := newWebhook(e, endpoint, webhookFilters)
+	if err != nil {
+		return job.Error(err)
+	}
+	job.Eng.OnShutdown(w.stop)
+	go w.run()
+	return engine.StatusOK
+}
+
+// webhook delivers events to a single HTTP endpoint
+type webhook struct {
+	url     string
+	filters filters.Args
+	client  *http.Client
+	// journal and cursorPath are empty if the events journal is not open
+	journal    *journal
+	cursorPath string
+
+	flushInterval time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+
+	mu      sync.Mutex
+	pending []*types.Event
+	// lost is set when pending events were discarded and must be read
+	// from the journal, starting at cursor
+	lost   bool
+	cursor int64
+	notify chan struct{}
+
+	events   *Events
+	listener chan *types.Event
+	// done is closed by stop
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newWebhook(e *Events, endpoint string, webhookFilters filters.Args) (*webhook, error) {
+	w := &webhook{
+		url:           endpoint,
+		filters:       webhookFilters,
+		client:        &http.Client{Timeout: webhookTimeout},
+		flushInterval: webhookFlushInterval,
+		minBackoff:    webhookMinBackoff,
+		maxBackoff:    webhookMaxBackoff,
+		// events lost before the first delivery are read from the
+		// journal from now on, not from its start
+		cursor:   time.Now().Unix(),
+		notify:   make(chan struct{}, 1),
+		events:   e,
+		listener: make(chan *types.Event, webhookBatchSize),
+		done:     make(chan struct{}),
+	}
+	e.mu.RLock()
+	w.journal = e.journal
+	e.mu.RUnlock()
+	if w.journal != nil {
+		w.cursorPath = webhookCursorPath(w.journal.dir(), endpoint)
+		b, err := ioutil.ReadFile(w.cursorPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			// the daemon was restarted, resend what happened since the
+			// last delivered event
+			if w.cursor, err = strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64); err != nil {
+				return nil, fmt.Errorf("Invalid events webhook cursor %s: %s", w.cursorPath, err)
+			}
+			w.lost = true
+		}
+	}
+
+	e.subscribe(w.listener)
+	go w.collect(w.listener)
+	return w, nil
+}
+
+// stop stops the delivery of events to the endpoint. A batch being posted
+// is not waited for, and the cursor is left at the last delivered event.
+func (w *webhook) stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		w.events.unsubscribe(w.listener)
+	})
+}
+
+// webhookCursorPath returns the file holding the time of the last event
+// delivered to endpoint
+func webhookCursorPath(dir, endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+	return filepath.Join(dir, "webhook-"+hex.EncodeToString(sum[:])[:12])
+}
+
+// collect queues the events matching the filters of w until they are sent
+func (w *webhook) collect(listener <-chan *types.Event) {
+	for event := range listener {
+		if !matchEvent(event, w.filters) {
+			continue
+		}
+		w.mu.Lock()
+		if len(w.pending) >= webhookQueueSize {
+			if w.journal != nil {
+				w.pending = nil
+				w.lost = true
+			} else {
+				log.Errorf("Events webhook %s queue is full, discarding event %s of %s", w.url, w.pending[0].Action, w.pending[0].Actor.ID)
+				w.pending = w.pending[1:]
+			}
+		}
+		w.pending = append(w.pending, event)
+		w.mu.Unlock()
+		w.wakeup()
+	}
+}
+
+func (w *webhook) wakeup() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers the queued events until w is stopped
+func (w *webhook) run() {
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		w.mu.Lock()
+		if w.lost {
+			// newer events are queued again while the journal is read,
+			// resending some of them is fine
+			w.pending = nil
+			w.lost = false
+			since := w.cursor
+			w.mu.Unlock()
+			w.replay(since)
+			continue
+		}
+		n := len(w.pending)
+		w.mu.Unlock()
+
+		if n == 0 {
+			select {
+			case <-w.notify:
+			case <-w.done:
+			}
+			continue
+		}
+		if n < webhookBatchSize {
+			// give more events a chance to join the batch
+			if flush == nil {
+				flush = time.After(w.flushInterval)
+			}
+			select {
+			case <-w.notify:
+				continue
+			case <-w.done:
+				continue
+			case <-flush:
+			}
+		}
+		flush = nil
+
+		w.mu.Lock()
+		if n = len(w.pending); n > webhookBatchSize {
+			n = webhookBatchSize
+		}
+		batch := w.pending[:n:n]
+		w.pending = w.pending[n:]
+		w.mu.Unlock()
+		w.deliver(batch)
+	}
+}
+
+// replay sends the journaled events matching the filters of w, starting at
+// since
+func (w *webhook) replay(since int64) {
+	var batch []*types.Event
+	err := w.journal.read(since, 0, func(event *types.Event) error {
+		if !matchEvent(event, w.filters) {
+			return nil
+		}
+		batch = append(batch, event)
+		if len(batch) == webhookBatchSize {
+			if !w.deliver(batch) {
+				return errWebhookStopped
+			}
+			batch = nil
+		}
+		return nil
+	})
+	if err == errWebhookStopped {
+		return
+	}
+	if err != nil {
+		log.Errorf("Error reading events journal for webhook %s: %s", w.url, err)
+	}
+	if len(batch) > 0 {
+		w.deliver(batch)
+	}
+}
+
+// deliver sends batch until the endpoint accepts it, then moves the cursor
+// to the last event of batch. It returns false if w was stopped before batch
+// was delivered.
+func (w *webhook) deliver(batch []*types.Event) bool {
+	b, err := json.Marshal(batch)
+	if err != nil {
+		log.Errorf("Error encoding events for webhook %s: %s", w.url, err)
+		return true
+	}
+	backoff := w.minBackoff
+	for {
+		if err = w.post(b); err == nil {
+			break
+		}
+		log.Errorf("Error sending %d events to webhook %s, retrying in %s: %s", len(batch), w.url, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+			return false
+		}
+		if backoff *= 2; backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+	}
+
+	cursor := batch[len(batch)-1].Time
+	w.mu.Lock()
+	w.cursor = cursor
+	w.mu.Unlock()
+	if w.cursorPath != "" {
+		tmp := w.cursorPath + ".tmp"
+		if err := ioutil.WriteFile(tmp, []byte(strconv.FormatInt(cursor, 10)), 0600); err != nil {
+			log.Errorf("Error saving events webhook cursor: %s", err)
+			return true
+		}
+		if err := os.Rename(tmp, w.cursorPath); err != nil {
+			log.Errorf("Error saving events webhook cursor: %s", err)
+		}
+	}
+	return true
+}
+
+func (w *webhook) post(body []byte) error {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestParseWebhook(t *testing.T) {
+	url, webhookFilters, err := ParseWebhook("http://collector:8080/events,type=container,label=env=prod,label=tier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "http://collector:8080/events" {
+		t.Fatalf("Wrong url: %s", url)
+	}
+	if len(webhookFilters["type"]) != 1 || webhookFilters["type"][0] != "container" {
+		t.Fatalf("Wrong type filter: %v", webhookFilters)
+	}
+	if len(webhookFilters["label"]) != 2 || webhookFilters["label"][0] != "env=prod" || webhookFilters["label"][1] != "tier" {
+		t.Fatalf("Wrong label filter: %v", webhookFilters)
+	}
+
+	// the URL ends at the first filter, not at the first comma
+	endpoint, webhookFilters, err := ParseWebhook("http://collector:8080/events?tags=a,b,c=d,type=container")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint != "http://collector:8080/events?tags=a,b,c=d" {
+		t.Fatalf("Wrong url: %s", endpoint)
+	}
+	if len(webhookFilters) != 1 || len(webhookFilters["type"]) != 1 || webhookFilters["type"][0] != "container" {
+		t.Fatalf("Wrong filters: %v", webhookFilters)
+	}
+
+	for _, spec := range []string{
+		"",
+		"collector:8080",
+		"ftp://collector/events",
+		"type=container",
+		"http://collector/events,type=container,type",
+		"http://collector/events,type=container,colour=red",
+	} {
+		if _, _, err := ParseWebhook(spec); err == nil {
+			t.Fatalf("Expected error for %q", spec)
+		}
+	}
+}
+
+// webhookReceiver records the events POSTed to it, failing the first
+// failures requests
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	events   []types.Event
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var batch []types.Event
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.events = append(r.events, batch...)
+}
+
+func (r *webhookReceiver) waitEvents(t *testing.T, n int) []types.Event {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		r.mu.Lock()
+		events := r.events
+		r.mu.Unlock()
+		if len(events) >= n {
+			return events
+		}
+	}
+	t.Fatalf("Webhook did not receive %d events in 5 seconds", n)
+	return nil
+}
+
+// startTestWebhook starts a webhook for spec, and returns it with a channel
+// closed when it stops running
+func startTestWebhook(t *testing.T, e *Events, spec string) (*webhook, chan struct{}) {
+	endpoint, webhookFilters, err := ParseWebhook(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := newWebhook(e, endpoint, webhookFilters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.flushInterval = 10 * time.Millisecond
+	w.minBackoff = 10 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		w.run()
+		close(done)
+	}()
+	return w, done
+}
+
+func TestWebhookRetry(t *testing.T) {
+	receiver := &webhookReceiver{failures: 2}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	e := New()
+	w, _ := startTestWebhook(t, e, srv.URL+",type=container")
+	defer w.stop()
+	e.log(types.ContainerEventType, "start", "cont_1", "image_1", nil, nil)
+	e.log(types.ImageEventType, "pull", "image_1", "", nil, nil)
+	e.log(types.ContainerEventType, "die", "cont_1", "image_1", nil, nil)
+
+	events := receiver.waitEvents(t, 2)
+	// give a chance to unexpected events to arrive
+	time.Sleep(50 * time.Millisecond)
+	receiver.mu.Lock()
+	events = receiver.events
+	receiver.mu.Unlock()
+	if len(events) != 2 || events[0].Action != "start" || events[1].Action != "die" {
+		t.Fatalf("Wrong events: %+v", events)
+	}
+}
+
+func TestWebhookReplayFromJournal(t *testing.T) {
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	tmp, err := ioutil.TempDir("", "docker-events-webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	e := New()
+	j, err := openJournal(tmp, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.close()
+	e.journal = j
+	e.log(types.ContainerEventType, "create", "cont_1", "image_1", nil, nil)
+	e.log(types.ContainerEventType, "start", "cont_1", "image_1", nil, nil)
+
+	// a cursor is left by a previous daemon which did not deliver anything
+	// newer than the first event
+	cursorPath := webhookCursorPath(tmp, srv.URL)
+	if err := ioutil.WriteFile(cursorPath, []byte("1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, _ := startTestWebhook(t, e, srv.URL)
+	defer w.stop()
+	e.log(types.ContainerEventType, "die", "cont_1", "image_1", nil, nil)
+
+	events := receiver.waitEvents(t, 3)
+	var actions []string
+	for _, ev := range events {
+		actions = append(actions, ev.Action)
+	}
+	if actions[0] != "create" || actions[1] != "start" || actions[len(actions)-1] != "die" {
+		t.Fatalf("Wrong events: %v", actions)
+	}
+
+	// the cursor is saved once the endpoint answered
+	expected := strconv.FormatInt(events[len(events)-1].Time, 10)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		b, err := ioutil.ReadFile(cursorPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) == expected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Wrong cursor %q, expected %s", b, expected)
+		}
+	}
+}
+
+func TestWebhookLostEventsWithoutCursor(t *testing.T) {
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	tmp, err := ioutil.TempDir("", "docker-events-webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	e := New()
+	j, err := openJournal(tmp, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.close()
+	e.journal = j
+	if err := j.append(&types.Event{Status: "start", ID: "cont_1", Type: types.ContainerEventType, Action: "start", Time: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// events lost by a webhook which never delivered any are read from the
+	// journal from the time it started
+	w, _ := startTestWebhook(t, e, srv.URL)
+	defer w.stop()
+	w.mu.Lock()
+	w.lost = true
+	w.mu.Unlock()
+	e.log(types.ContainerEventType, "die", "cont_1", "image_1", nil, nil)
+
+	events := receiver.waitEvents(t, 1)
+	// give a chance to unexpected events to arrive
+	time.Sleep(50 * time.Millisecond)
+	receiver.mu.Lock()
+	events = receiver.events
+	receiver.mu.Unlock()
+	for _, ev := range events {
+		if ev.Action != "die" {
+			t.Fatalf("Wrong events: %+v", events)
+		}
+	}
+}
+
+func TestWebhookStop(t *testing.T) {
+	receiver := &webhookReceiver{failures: 1000}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	e := New()
+	w, done := startTestWebhook(t, e, srv.URL)
+	e.log(types.ContainerEventType, "start", "cont_1", "image_1", nil, nil)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		receiver.mu.Lock()
+		failures := receiver.failures
+		receiver.mu.Unlock()
+		if failures < 1000 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Webhook did not try to deliver in 5 seconds")
+		}
+	}
+
+	// the webhook stops while it retries a batch
+	w.stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook did not stop in 5 seconds")
+	}
+	if count := e.subscribersCount(); count != 0 {
+		t.Fatalf("Expected no subscriber after stop, got %d", count)
+	}
+	w.stop()
+}
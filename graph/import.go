@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"net/url"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/progressreader"
@@ -90,8 +89,6 @@ func (s *TagStore) CmdImport(job *engine.Job) engine.Status {
 	if tag != "" {
 		logID = utils.ImageReference(logID, tag)
 	}
-	if err = job.Eng.Job("log", "import", logID, "").Run(); err != nil {
-		log.Errorf("Error logging event 'import' for %s: %s", logID, err)
-	}
+	s.logImageEvent(job.Eng, "import", logID)
 	return engine.StatusOK
 }
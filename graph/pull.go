@@ -84,9 +84,7 @@ func (s *TagStore) CmdPull(job *engine.Job) engine.Status {
 
 		log.Debugf("pulling v2 repository with local name %q", repoInfo.LocalName)
 		if err := s.pullV2Repository(job.Eng, r, job.Stdout, repoInfo, tag, sf, job.GetenvBool("parallel")); err == nil {
-			if err = job.Eng.Job("log", "pull", logName, "").Run(); err != nil {
-				log.Errorf("Error logging event 'pull' for %s: %s", logName, err)
-			}
+			s.logImageEvent(job.Eng, "pull", logName)
 			return engine.StatusOK
 		} else if err != registry.ErrDoesNotExist && err != ErrV2RegistryUnavailable {
 			log.Errorf("Error from V2 registry: %s", err)
@@ -100,9 +98,7 @@ func (s *TagStore) CmdPull(job *engine.Job) engine.Status {
 		return job.Error(err)
 	}
 
-	if err = job.Eng.Job("log", "pull", logName, "").Run(); err != nil {
-		log.Errorf("Error logging event 'pull' for %s: %s", logName, err)
-	}
+	s.logImageEvent(job.Eng, "pull", logName)
 
 	return engine.StatusOK
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/image"
 )
@@ -180,3 +181,29 @@ func (s *TagStore) CmdTarLayer(job *engine.Job) engine.Status {
 	}
 	return job.Errorf("No such image: %s", name)
 }
+
+// logImageEvent logs an event of the image with the given ID or reference
+func (s *TagStore) logImageEvent(eng *engine.Engine, action, id string) {
+	img, err := s.LookupImage(id)
+	if err != nil {
+		img = nil
+	}
+	LogImageEvent(eng, action, id, img, "")
+}
+
+// LogImageEvent logs an event of the image with the given ID or reference,
+// with the labels of img, which may be nil. name is the attribute of the
+// events about one of its references.
+func LogImageEvent(eng *engine.Engine, action, id string, img *image.Image, name string) {
+	job := eng.Job("log", action, id, "")
+	job.Setenv("Type", types.ImageEventType)
+	if name != "" {
+		job.SetenvJson("Attributes", map[string]string{"name": name})
+	}
+	if img != nil && img.Config != nil && len(img.Config.Labels) > 0 {
+		job.SetenvJson("Labels", img.Config.Labels)
+	}
+	if err := job.Run(); err != nil {
+		log.Errorf("Error logging event '%s' for %s: %s", action, id, err)
+	}
+}
@@ -109,6 +109,21 @@ func TestRunWithUlimits(t *testing.T) {
 	logDone("run - ulimits are set")
 }
 
+func TestRunInitWithUser(t *testing.T) {
+	testRequires(t, NativeExecDriver)
+	defer deleteAllContainers()
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--init", "--user", "1000", "busybox", "id", "-u"))
+	if err != nil {
+		t.Fatal(err, out)
+	}
+
+	if uid := strings.TrimSpace(out); uid != "1000" {
+		t.Fatalf("expected the command to run as uid 1000, got %s", uid)
+	}
+
+	logDone("run - init with a non-root user")
+}
+
 func TestRunContainerWithCgroupParent(t *testing.T) {
 	testRequires(t, NativeExecDriver)
 	defer deleteAllContainers()
@@ -48,6 +48,10 @@ func UlimitMapVar(values map[string]*ulimit.Ulimit, names []string, usage string
 	flag.Var(NewUlimitOpt(values), names, usage)
 }
 
+func LogOptsVar(values map[string]string, names []string, usage string) {
+	flag.Var(NewMapOpts(values, ValidateLogOpt), names, usage)
+}
+
 // ListOpts type
 type ListOpts struct {
 	values    *[]string
@@ -126,6 +130,49 @@ func (opts *ListOpts) Len() int {
 	return len((*opts.values))
 }
 
+// MapOpts holds a map of key=value options, validating each value if needed.
+type MapOpts struct {
+	values    map[string]string
+	validator ValidatorFctType
+}
+
+func NewMapOpts(values map[string]string, validator ValidatorFctType) *MapOpts {
+	if values == nil {
+		values = make(map[string]string)
+	}
+	return &MapOpts{
+		values:    values,
+		validator: validator,
+	}
+}
+
+// Set validates if needed the input value and stores it under its key.
+func (opts *MapOpts) Set(value string) error {
+	if opts.validator != nil {
+		v, err := opts.validator(value)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+	vals := strings.SplitN(value, "=", 2)
+	if len(vals) == 1 {
+		opts.values[vals[0]] = ""
+	} else {
+		opts.values[vals[0]] = vals[1]
+	}
+	return nil
+}
+
+func (opts *MapOpts) String() string {
+	return fmt.Sprintf("%v", map[string]string((opts.values)))
+}
+
+// GetAll returns the values' map.
+func (opts *MapOpts) GetAll() map[string]string {
+	return opts.values
+}
+
 // Validators
 type ValidatorFctType func(val string) (string, error)
 type ValidatorFctListType func(val string) ([]string, error)
@@ -140,6 +187,13 @@ func ValidateAttach(val string) (string, error) {
 	return val, fmt.Errorf("valid streams are STDIN, STDOUT and STDERR.")
 }
 
+func ValidateLogOpt(val string) (string, error) {
+	if strings.Count(val, "=") < 1 || strings.HasPrefix(val, "=") {
+		return "", fmt.Errorf("bad log option format: %s (expected key=value)", val)
+	}
+	return val, nil
+}
+
 func ValidateLink(val string) (string, error) {
 	if _, err := parsers.PartParser("name:alias", val); err != nil {
 		return val, err
@@ -229,6 +283,33 @@ func ValidateExtraHost(val string) (string, error) {
 	return val, nil
 }
 
+// ValidateSysctl validates a sysctl of the form key=value. Only the sysctls
+// namespaced by the kernel are allowed, the others would change the host.
+func ValidateSysctl(val string) (string, error) {
+	arr := strings.SplitN(val, "=", 2)
+	if len(arr) != 2 || arr[0] == "" {
+		return "", fmt.Errorf("bad format for sysctl: %q (expected key=value)", val)
+	}
+	if !IsNamespacedSysctl(arr[0]) {
+		return "", fmt.Errorf("sysctl %q is not namespaced and can't be set in a container", arr[0])
+	}
+	return val, nil
+}
+
+// IsNamespacedSysctl returns whether the sysctl key is namespaced, by the
+// network namespace for net.* or else the IPC namespace
+func IsNamespacedSysctl(key string) bool {
+	if strings.Contains(key, "/") || strings.Contains(key, "..") || strings.HasSuffix(key, ".") {
+		return false
+	}
+	for _, prefix := range []string{"net.", "kernel.shm", "kernel.msg", "fs.mqueue."} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return key == "kernel.sem"
+}
+
 func ValidateLabel(val string) (string, error) {
 	if strings.Count(val, "=") != 1 {
 		return "", fmt.Errorf("bad attribute format: %s", val)
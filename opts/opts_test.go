@@ -152,3 +152,36 @@ func TestValidateExtraHosts(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateSysctl(t *testing.T) {
+	valid := []string{
+		"net.core.somaxconn=1024",
+		"net.ipv4.tcp_keepalive_time=600",
+		"kernel.shmmax=68719476736",
+		"kernel.msgmax=65536",
+		"kernel.sem=250 32000 100 128",
+		"fs.mqueue.msg_max=100",
+	}
+	invalid := map[string]string{
+		"net.core.somaxconn":    "bad format",
+		"=1024":                 "bad format",
+		"kernel.hostname=foo":   "not namespaced",
+		"vm.swappiness=10":      "not namespaced",
+		"net.ipv4/../../vm/x=1": "not namespaced",
+		"net..core=1":           "not namespaced",
+		"fs.file-max=100000":    "not namespaced",
+	}
+
+	for _, sysctl := range valid {
+		if _, err := ValidateSysctl(sysctl); err != nil {
+			t.Fatalf("ValidateSysctl(%q) should succeed: error %v", sysctl, err)
+		}
+	}
+	for sysctl, expectedError := range invalid {
+		if _, err := ValidateSysctl(sysctl); err == nil {
+			t.Fatalf("ValidateSysctl(%q) should have failed validation", sysctl)
+		} else if !strings.Contains(err.Error(), expectedError) {
+			t.Fatalf("ValidateSysctl(%q) error should contain %q, got %v", sysctl, expectedError, err)
+		}
+	}
+}
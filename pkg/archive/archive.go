@@ -20,6 +20,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/pools"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/system"
@@ -35,12 +36,19 @@ type (
 		Compression     Compression
 		NoLchown        bool
 		Name            string
+		// UIDMaps and GIDMaps map the ids of the archive, which are the ids
+		// of a user namespace, to the ids of the host
+		UIDMaps []idtools.IDMap
+		GIDMaps []idtools.IDMap
 	}
 
 	// Archiver allows the reuse of most utility functions of this package
-	// with a pluggable Untar function.
+	// with a pluggable Untar function. The files it copies are owned by the
+	// ids of UIDMaps and GIDMaps on the host, if they are set.
 	Archiver struct {
-		Untar func(io.Reader, string, *TarOptions) error
+		Untar   func(io.Reader, string, *TarOptions) error
+		UIDMaps []idtools.IDMap
+		GIDMaps []idtools.IDMap
 	}
 
 	// breakoutError is used to differentiate errors related to breaking out
@@ -51,7 +59,7 @@ type (
 
 var (
 	ErrNotImplemented = errors.New("Function not implemented")
-	defaultArchiver   = &Archiver{Untar}
+	defaultArchiver   = &Archiver{Untar: Untar}
 )
 
 const (
@@ -170,6 +178,10 @@ type tarAppender struct {
 
 	// for hardlink mapping
 	SeenFiles map[uint64]string
+
+	// for mapping the ids of the files to the ids of a user namespace
+	UIDMaps []idtools.IDMap
+	GIDMaps []idtools.IDMap
 }
 
 // canonicalTarName provides a platform-independent and consistent posix-style
@@ -231,6 +243,15 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 		}
 	}
 
+	// the files are owned by the ids of the user namespace on the host, but
+	// the archive has the ids they have in the user namespace
+	if hdr.Uid, err = idtools.ToContainer(hdr.Uid, ta.UIDMaps); err != nil {
+		return err
+	}
+	if hdr.Gid, err = idtools.ToContainer(hdr.Gid, ta.GIDMaps); err != nil {
+		return err
+	}
+
 	capability, _ := system.Lgetxattr(path, "security.capability")
 	if capability != nil {
 		hdr.Xattrs = make(map[string]string)
@@ -263,6 +284,21 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 	return nil
 }
 
+// remapIDs maps the uid and gid of hdr, which are ids of a user namespace,
+// to the ids they have on the host
+func remapIDs(hdr *tar.Header, uidMaps, gidMaps []idtools.IDMap) error {
+	uid, err := idtools.ToHost(hdr.Uid, uidMaps)
+	if err != nil {
+		return err
+	}
+	gid, err := idtools.ToHost(hdr.Gid, gidMaps)
+	if err != nil {
+		return err
+	}
+	hdr.Uid, hdr.Gid = uid, gid
+	return nil
+}
+
 func createTarFile(path, extractDir string, hdr *tar.Header, reader io.Reader, Lchown bool) error {
 	// hdr.Mode is in linux format, which we can use for sycalls,
 	// but for os.Foo() calls we need the mode converted to os.FileMode,
@@ -407,6 +443,8 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 			TarWriter: tar.NewWriter(compressWriter),
 			Buffer:    pools.BufioWriter32KPool.Get(nil),
 			SeenFiles: make(map[uint64]string),
+			UIDMaps:   options.UIDMaps,
+			GIDMaps:   options.GIDMaps,
 		}
 		// this buffer is needed for the duration of this piped stream
 		defer pools.BufioWriter32KPool.Put(ta.Buffer)
@@ -559,6 +597,9 @@ loop:
 				}
 			}
 		}
+		if err := remapIDs(hdr, options.UIDMaps, options.GIDMaps); err != nil {
+			return err
+		}
 		trBuf.Reset(tr)
 		if err := createTarFile(path, dest, hdr, trBuf, !options.NoLchown); err != nil {
 			return err
@@ -612,7 +653,16 @@ func (archiver *Archiver) TarUntar(src, dst string) error {
 		return err
 	}
 	defer archive.Close()
-	return archiver.Untar(archive, dst, nil)
+	return archiver.Untar(archive, dst, archiver.untarOptions())
+}
+
+// untarOptions returns the options mapping the ids of the files untarred by
+// archiver
+func (archiver *Archiver) untarOptions() *TarOptions {
+	if archiver.UIDMaps == nil && archiver.GIDMaps == nil {
+		return nil
+	}
+	return &TarOptions{UIDMaps: archiver.UIDMaps, GIDMaps: archiver.GIDMaps}
 }
 
 // TarUntar is a convenience function which calls Tar and Untar, with the output of one piped into the other.
@@ -627,7 +677,7 @@ func (archiver *Archiver) UntarPath(src, dst string) error {
 		return err
 	}
 	defer archive.Close()
-	if err := archiver.Untar(archive, dst, nil); err != nil {
+	if err := archiver.Untar(archive, dst, archiver.untarOptions()); err != nil {
 		return err
 	}
 	return nil
@@ -714,7 +764,7 @@ func (archiver *Archiver) CopyFileWithTar(src, dst string) (err error) {
 			err = er
 		}
 	}()
-	return archiver.Untar(r, filepath.Dir(dst), nil)
+	return archiver.Untar(r, filepath.Dir(dst), archiver.untarOptions())
 }
 
 // CopyFileWithTar emulates the behavior of the 'cp' command-line
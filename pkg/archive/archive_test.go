@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+
+	"github.com/docker/docker/pkg/idtools"
 )
 
 func TestCmdStreamLargeStderr(t *testing.T) {
@@ -211,6 +213,47 @@ func TestTarWithOptions(t *testing.T) {
 	}
 }
 
+func TestTarUntarIDMaps(t *testing.T) {
+	origin, err := ioutil.TempDir("", "docker-test-untar-origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(origin)
+	dest, err := ioutil.TempDir("", "docker-test-untar-dest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	if err := ioutil.WriteFile(path.Join(origin, "1"), []byte("hello world"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Lchown(path.Join(origin, "1"), 100000, 1); err != nil {
+		t.Skipf("Can't change the owner of a file: %s", err)
+	}
+
+	uidMaps := []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	gidMaps := []idtools.IDMap{{ContainerID: 0, HostID: 200000, Size: 65536}}
+	archive, err := TarWithOptions(origin, &TarOptions{UIDMaps: uidMaps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	// the file is owned by 0:1 in the archive, and by 100000:200001 once
+	// untarred with the mappings
+	if err := Untar(archive, dest, &TarOptions{UIDMaps: uidMaps, GIDMaps: gidMaps}); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Lstat(path.Join(dest, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := fi.Sys().(*syscall.Stat_t); st.Uid != 100000 || st.Gid != 200001 {
+		t.Fatalf("Expected the file to be owned by 100000:200001, got %d:%d", st.Uid, st.Gid)
+	}
+}
+
 // Some tar archives such as http://haproxy.1wt.eu/download/1.5/src/devel/haproxy-1.5-dev21.tar.gz
 // use PAX Global Extended Headers.
 // Failing prevents the archives from being uncompressed during ADD
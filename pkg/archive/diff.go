@@ -15,7 +15,14 @@ import (
 	"github.com/docker/docker/pkg/system"
 )
 
-func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
+// UnpackLayer unpacks the diff of a layer in `layer` into the directory
+// `dest`, mapping the ids of its files with the UIDMaps and GIDMaps of options,
+// which may be nil. It returns the size in bytes of the contents of the layer.
+func UnpackLayer(dest string, layer ArchiveReader, options *TarOptions) (size int64, err error) {
+	if options == nil {
+		options = &TarOptions{}
+	}
+
 	tr := tar.NewReader(layer)
 	trBuf := pools.BufioReader32KPool.Get(tr)
 	defer pools.BufioReader32KPool.Put(trBuf)
@@ -38,6 +45,10 @@ func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
 
 		size += hdr.Size
 
+		if err := remapIDs(hdr, options.UIDMaps, options.GIDMaps); err != nil {
+			return 0, err
+		}
+
 		// Normalize name, for safety and for a simple is-root check
 		hdr.Name = filepath.Clean(hdr.Name)
 
@@ -165,5 +176,5 @@ func ApplyLayer(dest string, layer ArchiveReader) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return UnpackLayer(dest, layer)
+	return UnpackLayer(dest, layer, nil)
 }
@@ -11,11 +11,18 @@ import (
 	"syscall"
 
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/reexec"
 )
 
 var chrootArchiver = &archive.Archiver{Untar: Untar}
 
+// NewArchiver returns an archiver untarring in a chroot like the functions of
+// this package, whose files are owned by the ids of uidMaps and gidMaps
+func NewArchiver(uidMaps, gidMaps []idtools.IDMap) *archive.Archiver {
+	return &archive.Archiver{Untar: Untar, UIDMaps: uidMaps, GIDMaps: gidMaps}
+}
+
 func chroot(path string) error {
 	if err := syscall.Chroot(path); err != nil {
 		return err
@@ -0,0 +1,173 @@
+package idtools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// IDMap maps a range of uids or gids of a user namespace to the ids of
+// the host, like a line of /proc/<pid>/uid_map
+type IDMap struct {
+	ContainerID int `json:"container_id"`
+	HostID      int `json:"host_id"`
+	Size        int `json:"size"`
+}
+
+// subIDRange is a range of subordinate ids of /etc/subuid or /etc/subgid
+type subIDRange struct {
+	Start  int
+	Length int
+}
+
+const (
+	subuidFileName = "/etc/subuid"
+	subgidFileName = "/etc/subgid"
+)
+
+// MkdirAllAs creates a directory like os.MkdirAll, and changes the owner of
+// the directories it created, and of path itself, to ownerUID:ownerGID
+func MkdirAllAs(path string, mode os.FileMode, ownerUID, ownerGID int) error {
+	path = filepath.Clean(path)
+
+	var created []string
+	for p := path; ; p = filepath.Dir(p) {
+		if _, err := os.Stat(p); err == nil || !os.IsNotExist(err) {
+			break
+		}
+		created = append(created, p)
+		if p == filepath.Dir(p) {
+			break
+		}
+	}
+	if err := os.MkdirAll(path, mode); err != nil {
+		return err
+	}
+	if len(created) == 0 || created[0] != path {
+		created = append(created, path)
+	}
+	for _, p := range created {
+		if err := os.Chown(p, ownerUID, ownerGID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRootUIDGID returns the host uid and gid of the root user of a user
+// namespace with the given mappings, which is 0 when there are none
+func GetRootUIDGID(uidMap, gidMap []IDMap) (int, int, error) {
+	uid, err := ToHost(0, uidMap)
+	if err != nil {
+		return -1, -1, err
+	}
+	gid, err := ToHost(0, gidMap)
+	if err != nil {
+		return -1, -1, err
+	}
+	return uid, gid, nil
+}
+
+// ToContainer maps the host id hostID to the id it has in the user
+// namespace of idMap. Without mappings the id is returned unchanged.
+func ToContainer(hostID int, idMap []IDMap) (int, error) {
+	if idMap == nil {
+		return hostID, nil
+	}
+	for _, m := range idMap {
+		if hostID >= m.HostID && hostID < m.HostID+m.Size {
+			return m.ContainerID + (hostID - m.HostID), nil
+		}
+	}
+	return -1, fmt.Errorf("Host ID %d cannot be mapped to a container ID", hostID)
+}
+
+// ToHost maps the id contID of the user namespace of idMap to the id it has
+// on the host. Without mappings the id is returned unchanged.
+func ToHost(contID int, idMap []IDMap) (int, error) {
+	if idMap == nil {
+		return contID, nil
+	}
+	for _, m := range idMap {
+		if contID >= m.ContainerID && contID < m.ContainerID+m.Size {
+			return m.HostID + (contID - m.ContainerID), nil
+		}
+	}
+	return -1, fmt.Errorf("Container ID %d cannot be mapped to a host ID", contID)
+}
+
+// CreateIDMappings returns the uid and gid mappings of a user namespace
+// whose ids are the subordinate ids given to username in /etc/subuid and
+// groupname in /etc/subgid
+func CreateIDMappings(username, groupname string) ([]IDMap, []IDMap, error) {
+	subuidRanges, err := parseSubIDFile(subuidFileName, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(subuidRanges) == 0 {
+		return nil, nil, fmt.Errorf("No subuid ranges found for user %q in %s", username, subuidFileName)
+	}
+	subgidRanges, err := parseSubIDFile(subgidFileName, groupname)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(subgidRanges) == 0 {
+		return nil, nil, fmt.Errorf("No subgid ranges found for group %q in %s", groupname, subgidFileName)
+	}
+	return createIDMap(subuidRanges), createIDMap(subgidRanges), nil
+}
+
+// createIDMap maps the ranges one after the other in the user namespace,
+// starting from its root
+func createIDMap(subidRanges []subIDRange) []IDMap {
+	idMap := []IDMap{}
+	containerID := 0
+	for _, r := range subidRanges {
+		idMap = append(idMap, IDMap{
+			ContainerID: containerID,
+			HostID:      r.Start,
+			Size:        r.Length,
+		})
+		containerID += r.Length
+	}
+	return idMap
+}
+
+// parseSubIDFile returns the ranges given to name in a file with the format
+// of /etc/subuid, where each line is name:start:length
+func parseSubIDFile(path, name string) ([]subIDRange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ranges []subIDRange
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("Cannot parse %s: invalid line %q", path, line)
+		}
+		if parts[0] != name {
+			continue
+		}
+		start, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("Cannot parse %s: invalid start of range in %q", path, line)
+		}
+		length, err := strconv.Atoi(parts[2])
+		if err != nil || length <= 0 {
+			return nil, fmt.Errorf("Cannot parse %s: invalid length of range in %q", path, line)
+		}
+		ranges = append(ranges, subIDRange{start, length})
+	}
+	return ranges, s.Err()
+}
@@ -0,0 +1,79 @@
+package idtools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSubIDFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "subuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# ranges\nother:100000:65536\ndockremap:165536:65536\ndockremap:300000:1000\n")
+	f.Close()
+
+	ranges, err := parseSubIDFile(f.Name(), "dockremap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 2 || ranges[0] != (subIDRange{165536, 65536}) || ranges[1] != (subIDRange{300000, 1000}) {
+		t.Fatalf("Unexpected ranges %v", ranges)
+	}
+
+	idMap := createIDMap(ranges)
+	expected := []IDMap{{0, 165536, 65536}, {65536, 300000, 1000}}
+	if len(idMap) != 2 || idMap[0] != expected[0] || idMap[1] != expected[1] {
+		t.Fatalf("Expected the id map %v, got %v", expected, idMap)
+	}
+}
+
+func TestToHostToContainer(t *testing.T) {
+	idMap := []IDMap{{0, 165536, 65536}, {65536, 300000, 1000}}
+	for _, c := range []struct{ contID, hostID int }{{0, 165536}, {1000, 166536}, {65536, 300000}} {
+		hostID, err := ToHost(c.contID, idMap)
+		if err != nil || hostID != c.hostID {
+			t.Fatalf("Expected %d to map to the host ID %d, got %d (%v)", c.contID, c.hostID, hostID, err)
+		}
+		contID, err := ToContainer(c.hostID, idMap)
+		if err != nil || contID != c.contID {
+			t.Fatalf("Expected %d to map to the container ID %d, got %d (%v)", c.hostID, c.contID, contID, err)
+		}
+	}
+	if _, err := ToHost(70000, idMap); err == nil {
+		t.Fatal("Expected an error for an ID out of the mappings")
+	}
+	if _, err := ToContainer(0, idMap); err == nil {
+		t.Fatal("Expected an error for the host root, which isn't mapped")
+	}
+	if id, err := ToHost(42, nil); err != nil || id != 42 {
+		t.Fatalf("Expected the ID to be unchanged without mappings, got %d (%v)", id, err)
+	}
+
+	uid, gid, err := GetRootUIDGID(idMap, []IDMap{{0, 200000, 65536}})
+	if err != nil || uid != 165536 || gid != 200000 {
+		t.Fatalf("Unexpected root %d:%d (%v)", uid, gid, err)
+	}
+}
+
+func TestMkdirAllAs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestMkdirAllAs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a", "b")
+	if err := MkdirAllAs(path, 0700, os.Getuid(), os.Getgid()); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		t.Fatalf("Expected %s to be created: %v", path, err)
+	}
+	if err := MkdirAllAs(path, 0700, os.Getuid(), os.Getgid()); err != nil {
+		t.Fatalf("Expected no error for an existing directory: %v", err)
+	}
+}
@@ -10,9 +10,10 @@ import (
 )
 
 type JSONLog struct {
-	Log     string    `json:"log,omitempty"`
-	Stream  string    `json:"stream,omitempty"`
-	Created time.Time `json:"time"`
+	Log     string            `json:"log,omitempty"`
+	Stream  string            `json:"stream,omitempty"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+	Created time.Time         `json:"time"`
 }
 
 func (jl *JSONLog) Format(format string) (string, error) {
@@ -29,6 +30,7 @@ func (jl *JSONLog) Format(format string) (string, error) {
 func (jl *JSONLog) Reset() {
 	jl.Log = ""
 	jl.Stream = ""
+	jl.Attrs = nil
 	jl.Created = time.Time{}
 }
 
@@ -57,6 +57,7 @@ package jsonlog
 
 import (
 	"bytes"
+	"sort"
 	"unicode/utf8"
 
 	"github.com/docker/docker/pkg/timeutils"
@@ -97,6 +98,15 @@ func (mj *JSONLog) MarshalJSONBuf(buf *bytes.Buffer) error {
 		buf.WriteString(`"stream":`)
 		ffjson_WriteJsonString(buf, mj.Stream)
 	}
+	if len(mj.Attrs) != 0 {
+		if first == true {
+			first = false
+		} else {
+			buf.WriteString(`,`)
+		}
+		buf.WriteString(`"attrs":`)
+		ffjson_WriteJsonStringMap(buf, mj.Attrs)
+	}
 	if first == true {
 		first = false
 	} else {
@@ -112,6 +122,26 @@ func (mj *JSONLog) MarshalJSONBuf(buf *bytes.Buffer) error {
 	return nil
 }
 
+// ffjson_WriteJsonStringMap writes m as JSON object with sorted keys, so
+// that output is stable between log records.
+func ffjson_WriteJsonStringMap(buf *bytes.Buffer, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf.WriteString(`{`)
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(`,`)
+		}
+		ffjson_WriteJsonString(buf, k)
+		buf.WriteString(`:`)
+		ffjson_WriteJsonString(buf, m[k])
+	}
+	buf.WriteString(`}`)
+}
+
 func ffjson_WriteJsonString(buf *bytes.Buffer, s string) {
 	const hex = "0123456789abcdef"
 
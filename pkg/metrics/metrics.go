@@ -0,0 +1,206 @@
+// Package metrics writes metrics in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets are the upper bounds of histogram buckets suited to
+// latencies in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Labels are the label names and values of a sample
+type Labels map[string]string
+
+// Sink receives metric families and their samples, it is implemented by
+// Writer and by the sinks which push metrics
+type Sink interface {
+	Family(name, typ, help string)
+	Sample(name string, labels Labels, value float64)
+}
+
+// Writer writes metric families. Write errors are kept, the first one is
+// returned by Err.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Family writes the HELP and TYPE lines of metric name, they must precede
+// the samples of the metric
+func (w *Writer) Family(name, typ, help string) {
+	help = strings.Replace(strings.Replace(help, `\`, `\\`, -1), "\n", `\n`, -1)
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// Sample writes a sample of metric name
+func (w *Writer) Sample(name string, labels Labels, value float64) {
+	w.printf("%s%s %s\n", name, formatLabels(labels), formatValue(value))
+}
+
+// Err returns the first error which happened while writing
+func (w *Writer) Err() error {
+	return w.err
+}
+
+func (w *Writer) printf(format string, a ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, a...)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Histogram is a cumulative histogram of observed values, safe for concurrent
+// use
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram returns a histogram with the given increasing bucket upper
+// bounds, a +Inf bucket is always added
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// Observe adds v to the histogram
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Write writes the bucket, sum and count samples of h as metric name
+func (h *Histogram) Write(w *Writer, name string, labels Labels) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	bucketLabels := make(Labels, len(labels)+1)
+	for k, v := range labels {
+		bucketLabels[k] = v
+	}
+	for i, bound := range h.buckets {
+		bucketLabels["le"] = formatValue(bound)
+		w.Sample(name+"_bucket", bucketLabels, float64(h.counts[i]))
+	}
+	bucketLabels["le"] = "+Inf"
+	w.Sample(name+"_bucket", bucketLabels, float64(h.count))
+	w.Sample(name+"_sum", labels, h.sum)
+	w.Sample(name+"_count", labels, float64(h.count))
+}
+
+// HistogramVec is a set of histograms with the same buckets, told apart by the
+// values of a fixed list of labels
+type HistogramVec struct {
+	mu         sync.Mutex
+	buckets    []float64
+	labelNames []string
+	hists      map[string]*Histogram
+	labels     map[string]Labels
+}
+
+func NewHistogramVec(buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{
+		buckets:    buckets,
+		labelNames: labelNames,
+		hists:      make(map[string]*Histogram),
+		labels:     make(map[string]Labels),
+	}
+}
+
+// With returns the histogram of the given label values, in the order of the
+// label names of v
+func (v *HistogramVec) With(values ...string) *Histogram {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %d label values given for %d labels", len(values), len(v.labelNames)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	h, ok := v.hists[key]
+	if !ok {
+		h = NewHistogram(v.buckets)
+		labels := make(Labels, len(values))
+		for i, name := range v.labelNames {
+			labels[name] = values[i]
+		}
+		v.hists[key] = h
+		v.labels[key] = labels
+	}
+	return h
+}
+
+// Write writes all histograms of v as metric name, ordered by label values
+func (v *HistogramVec) Write(w *Writer, name string) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.hists))
+	for key := range v.hists {
+		keys = append(keys, key)
+	}
+	v.mu.Unlock()
+	sort.Strings(keys)
+	for _, key := range keys {
+		v.mu.Lock()
+		h, labels := v.hists[key], v.labels[key]
+		v.mu.Unlock()
+		h.Write(w, name, labels)
+	}
+}
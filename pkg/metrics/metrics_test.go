@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Family("test_bytes", TypeGauge, "Bytes of \\ things\nover lines.")
+	w.Sample("test_bytes", nil, 1024)
+	w.Sample("test_bytes", Labels{"name": `say "hi"`, "id": "a\\b"}, 0.5)
+	w.Sample("test_bytes", Labels{"id": "c"}, math.Inf(1))
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP test_bytes Bytes of \\ things\nover lines.
+# TYPE test_bytes gauge
+test_bytes 1024
+test_bytes{id="a\\b",name="say \"hi\""} 0.5
+test_bytes{id="c"} +Inf
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	v := NewHistogramVec([]float64{0.1, 1}, "method", "route")
+	v.With("GET", "/info").Observe(0.05)
+	v.With("GET", "/info").Observe(0.5)
+	v.With("GET", "/info").Observe(3)
+	v.With("DELETE", "/images").Observe(0.1)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	v.Write(w, "latency")
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `latency_bucket{le="0.1",method="DELETE",route="/images"} 1
+latency_bucket{le="1",method="DELETE",route="/images"} 1
+latency_bucket{le="+Inf",method="DELETE",route="/images"} 1
+latency_sum{method="DELETE",route="/images"} 0.1
+latency_count{method="DELETE",route="/images"} 1
+latency_bucket{le="0.1",method="GET",route="/info"} 1
+latency_bucket{le="1",method="GET",route="/info"} 2
+latency_bucket{le="+Inf",method="GET",route="/info"} 3
+latency_sum{method="GET",route="/info"} 3.55
+latency_count{method="GET",route="/info"} 3
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
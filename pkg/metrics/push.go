@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxStatsdPacket keeps StatsD packets within the payload of an
+	// ethernet frame
+	maxStatsdPacket = 1432
+	pushTimeout     = 10 * time.Second
+)
+
+// PushSample is a sample sent by a Pusher, Path is the dot separated name of
+// the metric
+type PushSample struct {
+	Path  string
+	Value float64
+}
+
+// Pusher sends samples to a StatsD server over UDP, as gauges, or to a
+// Graphite server over TCP, in their plaintext line protocols
+type Pusher struct {
+	scheme string
+	addr   string
+	conn   net.Conn
+}
+
+// NewPusher returns a Pusher for target, either statsd://HOST:PORT or
+// graphite://HOST:PORT
+func NewPusher(target string) (*Pusher, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "statsd" && u.Scheme != "graphite") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid metrics push target %q, must be statsd://HOST:PORT or graphite://HOST:PORT", target)
+	}
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return nil, fmt.Errorf("Invalid metrics push target %q: %s", target, err)
+	}
+	return &Pusher{scheme: u.Scheme, addr: u.Host}, nil
+}
+
+// Push sends samples read at t. The connection is closed on error and opened
+// again by the next push.
+func (p *Pusher) Push(samples []PushSample, t time.Time) error {
+	if p.conn == nil {
+		network := "tcp"
+		if p.scheme == "statsd" {
+			network = "udp"
+		}
+		conn, err := net.DialTimeout(network, p.addr, pushTimeout)
+		if err != nil {
+			return err
+		}
+		p.conn = conn
+	}
+	p.conn.SetWriteDeadline(time.Now().Add(pushTimeout))
+	var err error
+	if p.scheme == "statsd" {
+		err = p.pushStatsd(samples)
+	} else {
+		err = p.pushGraphite(samples, t)
+	}
+	if err != nil {
+		p.Close()
+	}
+	return err
+}
+
+func (p *Pusher) pushStatsd(samples []PushSample) error {
+	var packet bytes.Buffer
+	for _, s := range samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		line := s.Path + ":" + strconv.FormatFloat(s.Value, 'f', -1, 64) + "|g"
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsdPacket {
+			if _, err := p.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() == 0 {
+		return nil
+	}
+	_, err := p.conn.Write(packet.Bytes())
+	return err
+}
+
+func (p *Pusher) pushGraphite(samples []PushSample, t time.Time) error {
+	var buf bytes.Buffer
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	for _, s := range samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s %s %s\n", s.Path, strconv.FormatFloat(s.Value, 'f', -1, 64), timestamp)
+	}
+	_, err := buf.WriteTo(p.conn)
+	return err
+}
+
+// Close closes the connection of p
+func (p *Pusher) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// EscapePath replaces the characters of s which are not letters, digits, '-'
+// or '_' by '_', so s can be used as one element of a metric path
+func EscapePath(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPusher(t *testing.T) {
+	for _, target := range []string{"statsd://localhost:8125", "graphite://10.0.0.1:2003"} {
+		if _, err := NewPusher(target); err != nil {
+			t.Fatalf("%s: %s", target, err)
+		}
+	}
+	for _, target := range []string{"", "localhost:8125", "http://localhost:8125", "statsd://localhost", "graphite://"} {
+		if _, err := NewPusher(target); err == nil {
+			t.Fatalf("Expected an error for %q", target)
+		}
+	}
+}
+
+func TestPushStatsd(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	p, err := NewPusher("statsd://" + l.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// enough samples to need two packets
+	var samples []PushSample
+	for i := 0; i < 100; i++ {
+		samples = append(samples, PushSample{Path: "docker.web.memory_usage_bytes", Value: 1024})
+	}
+	samples = append(samples, PushSample{Path: "docker.web.nan", Value: math.NaN()})
+	if err := p.Push(samples, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	buf := make([]byte, 65536)
+	l.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(lines) < 100 {
+		n, _, err := l.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("Got %d lines: %s", len(lines), err)
+		}
+		if n > maxStatsdPacket {
+			t.Fatalf("Packet of %d bytes is larger than %d", n, maxStatsdPacket)
+		}
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+	if len(lines) != 100 {
+		t.Fatalf("Expected 100 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if line != "docker.web.memory_usage_bytes:1024|g" {
+			t.Fatalf("Unexpected line %q", line)
+		}
+	}
+}
+
+func TestPushGraphite(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	p, err := NewPusher("graphite://" + l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	received := make(chan []string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		sc := bufio.NewScanner(conn)
+		for len(lines) < 3 && sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		received <- lines
+	}()
+
+	now := time.Unix(1420757851, 0)
+	if err := p.Push([]PushSample{{"docker.web.cpu_usage_seconds_total", 1.5}, {"docker.web.pids", 3}}, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Push([]PushSample{{"docker.containers.running", 2}}, now.Add(10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"docker.web.cpu_usage_seconds_total 1.5 1420757851",
+		"docker.web.pids 3 1420757851",
+		"docker.containers.running 2 1420757861",
+	}
+	select {
+	case lines := <-received:
+		if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the pushed metrics")
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	if s := EscapePath("registry:5000/my.app"); s != "registry_5000_my_app" {
+		t.Fatalf("Unexpected escaped path %q", s)
+	}
+}
@@ -22,6 +22,25 @@ func TestMountOptionsParsing(t *testing.T) {
 	}
 }
 
+func TestParseTmpfsOptions(t *testing.T) {
+	flag, data, err := ParseTmpfsOptions("noexec,nosuid,exec,size=64m,mode=1777")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != "size=64m,mode=1777" {
+		t.Fatalf("Expected size=64m,mode=1777 got %s", data)
+	}
+	if flag != NOSUID {
+		t.Fatalf("Expected %d got %d", NOSUID, flag)
+	}
+
+	for _, options := range []string{"size=64m,foo=bar", "rbind", "shared", "remount,size=1m"} {
+		if _, _, err := ParseTmpfsOptions(options); err == nil {
+			t.Fatalf("Expected an error for the tmpfs options %q", options)
+		}
+	}
+}
+
 func TestMounted(t *testing.T) {
 	tmp := path.Join(os.TempDir(), "mount-tests")
 	if err := os.MkdirAll(tmp, 0777); err != nil {
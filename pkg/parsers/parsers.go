@@ -135,3 +135,35 @@ func ParsePortRange(ports string) (uint64, uint64, error) {
 	}
 	return start, end, nil
 }
+
+// ParseUintList parses a list of integers and ranges of the form of the
+// cpuset cgroup, e.g. "0-3,5,7-8", into the set of its integers.
+func ParseUintList(val string) (map[int]bool, error) {
+	if val == "" {
+		return nil, fmt.Errorf("Empty list")
+	}
+	available := make(map[int]bool)
+	for _, r := range strings.Split(val, ",") {
+		if !strings.Contains(r, "-") {
+			v, err := strconv.Atoi(r)
+			if err != nil || v < 0 {
+				return nil, fmt.Errorf("Invalid list: %s", val)
+			}
+			available[v] = true
+			continue
+		}
+		parts := strings.SplitN(r, "-", 2)
+		start, err := strconv.Atoi(parts[0])
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("Invalid list: %s", val)
+		}
+		end, err := strconv.Atoi(parts[1])
+		if err != nil || end < start {
+			return nil, fmt.Errorf("Invalid range in list: %s", val)
+		}
+		for i := start; i <= end; i++ {
+			available[i] = true
+		}
+	}
+	return available, nil
+}
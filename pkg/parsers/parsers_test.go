@@ -123,3 +123,33 @@ func TestParsePortRangeIncorrectStartRange(t *testing.T) {
 		t.Fatalf("Expecting error 'Invalid range specified for the Port' but received %s.", err)
 	}
 }
+
+func TestParseUintList(t *testing.T) {
+	valids := map[string]map[int]bool{
+		"0":       {0: true},
+		"0,1":     {0: true, 1: true},
+		"0-2":     {0: true, 1: true, 2: true},
+		"0-1,3,5": {0: true, 1: true, 3: true, 5: true},
+		"3-3":     {3: true},
+	}
+	for val, expected := range valids {
+		out, err := ParseUintList(val)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %s", val, err)
+		}
+		if len(out) != len(expected) {
+			t.Fatalf("Expected %v for %q, got %v", expected, val, out)
+		}
+		for k := range expected {
+			if !out[k] {
+				t.Fatalf("Expected %v for %q, got %v", expected, val, out)
+			}
+		}
+	}
+
+	for _, val := range []string{"", ",", "1,", "a", "-1", "1-", "3-1", "1--2", "0 ,1"} {
+		if _, err := ParseUintList(val); err == nil {
+			t.Fatalf("Expected an error for %q", val)
+		}
+	}
+}
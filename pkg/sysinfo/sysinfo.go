@@ -4,9 +4,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/seccomp"
 )
 
 type SysInfo struct {
@@ -14,6 +16,11 @@ type SysInfo struct {
 	SwapLimit              bool
 	IPv4ForwardingDisabled bool
 	AppArmor               bool
+	PidsLimit              bool
+	Seccomp                bool
+	// Cpus is the list of the CPUs of the cpuset cgroup, e.g. "0-3", or
+	// empty if it's unknown
+	Cpus string
 }
 
 func New(quiet bool) *SysInfo {
@@ -37,11 +44,28 @@ func New(quiet bool) *SysInfo {
 		}
 	}
 
+	if cgroupCpusetMountpoint, err := cgroups.FindCgroupMountpoint("cpuset"); err == nil {
+		if cpus, err := ioutil.ReadFile(path.Join(cgroupCpusetMountpoint, "cpuset.cpus")); err == nil {
+			sysInfo.Cpus = strings.TrimSpace(string(cpus))
+		}
+	}
+
+	_, err := cgroups.FindCgroupMountpoint("pids")
+	sysInfo.PidsLimit = err == nil
+	if !sysInfo.PidsLimit && !quiet {
+		log.Warnf("Your kernel does not support cgroup pids limit.")
+	}
+
 	// Check if AppArmor seems to be enabled on this system.
 	if _, err := os.Stat("/sys/kernel/security/apparmor"); os.IsNotExist(err) {
 		sysInfo.AppArmor = false
 	} else {
 		sysInfo.AppArmor = true
 	}
+
+	sysInfo.Seccomp = seccomp.IsEnabled()
+	if !sysInfo.Seccomp && !quiet {
+		log.Warnf("Your kernel does not support seccomp.")
+	}
 	return sysInfo
 }
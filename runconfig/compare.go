@@ -1,5 +1,7 @@
 package runconfig
 
+import "reflect"
+
 // Compare two Config struct. Do not compare the "Image" nor "Hostname" fields
 // If OpenStdin is set, then it differs
 func Compare(a, b *Config) bool {
@@ -62,5 +64,8 @@ func Compare(a, b *Config) bool {
 			return false
 		}
 	}
+	if !reflect.DeepEqual(a.Healthcheck, b.Healthcheck) {
+		return false
+	}
 	return true
 }
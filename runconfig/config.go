@@ -1,10 +1,25 @@
 package runconfig
 
 import (
+	"time"
+
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
 )
 
+// HealthConfig holds the health check of a container.
+type HealthConfig struct {
+	// Test is the check to run: {} inherits the check of the image,
+	// {"NONE"} disables it, {"CMD", args...} runs args and
+	// {"CMD-SHELL", command} runs command with /bin/sh -c
+	Test []string `json:",omitempty"`
+
+	// Zero means to inherit the values of the image, or the defaults
+	Interval time.Duration `json:",omitempty"` // Time between checks
+	Timeout  time.Duration `json:",omitempty"` // Time after which a check is considered to have failed
+	Retries  int           `json:",omitempty"` // Consecutive failures needed to consider the container unhealthy
+}
+
 // Note: the Config structure should hold only portable information about the container.
 // Here, "portable" means "independent from the host we are running on".
 // Non-portable information *should* appear in HostConfig.
@@ -37,6 +52,7 @@ type Config struct {
 	Labels          map[string]string
 	RestrictIP      string
 	MarkNum         int64
+	Healthcheck     *HealthConfig `json:",omitempty"`
 }
 
 func ContainerConfigFromJob(job *engine.Job) *Config {
@@ -75,6 +91,7 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 	}
 
 	job.GetenvJson("Labels", &config.Labels)
+	job.GetenvJson("Healthcheck", &config.Healthcheck)
 
 	if Entrypoint := job.GetenvList("Entrypoint"); Entrypoint != nil {
 		config.Entrypoint = Entrypoint
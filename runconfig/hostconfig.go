@@ -2,6 +2,7 @@ package runconfig
 
 import (
 	"strings"
+	"time"
 
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
@@ -98,9 +99,24 @@ type DeviceMapping struct {
 	CgroupPermissions string
 }
 
+// ThrottleDevice is the IO rate limit of a block device of the host
+type ThrottleDevice struct {
+	Path string
+	Rate uint64
+}
+
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
+	// GracePeriod is the time after a start during which an unhealthy
+	// container isn't restarted by the on-unhealthy policy
+	GracePeriod time.Duration `json:",omitempty"`
+	// Delay is the delay before a restart, it is doubled after each
+	// container which ran for less than ResetWindow, up to MaxDelay. The
+	// daemon's defaults are used for the zero values.
+	Delay       time.Duration `json:",omitempty"`
+	MaxDelay    time.Duration `json:",omitempty"`
+	ResetWindow time.Duration `json:",omitempty"`
 }
 
 type LogConfig struct {
@@ -116,6 +132,19 @@ type HostConfig struct {
 	MemorySwap      int64  // Total memory usage (memory + swap); set `-1` to disable swap
 	CpuShares       int64  // CPU shares (relative weight vs. other containers)
 	CpusetCpus      string // CpusetCpus 0-2, 0,1
+
+	CpuPeriod            int64 // CPU CFS (Completely Fair Scheduler) period (in microseconds)
+	CpuQuota             int64 // CPU CFS (Completely Fair Scheduler) quota (in microseconds)
+	BlkioWeight          int64 // Block IO weight (relative weight vs. other containers), 10 to 1000
+	BlkioDeviceReadBps   []ThrottleDevice
+	BlkioDeviceWriteBps  []ThrottleDevice
+	BlkioDeviceReadIOps  []ThrottleDevice
+	BlkioDeviceWriteIOps []ThrottleDevice
+	MemoryReservation    int64 // Memory soft limit (in bytes)
+	KernelMemory         int64 // Kernel memory limit (in bytes)
+	OomKillDisable       bool  // Whether to disable the OOM killer for the container
+	PidsLimit            int64 // Maximum number of processes in the container, 0 for no limit
+
 	Privileged      bool
 	PortBindings    nat.PortMap
 	Links           []string
@@ -133,7 +162,10 @@ type HostConfig struct {
 	RestartPolicy   RestartPolicy
 	SecurityOpt     []string
 	ReadonlyRootfs  bool
+	Init            bool // Run an init in the container that forwards signals and reaps processes
 	Ulimits         []*ulimit.Ulimit
+	Sysctls         map[string]string
+	Tmpfs           map[string]string // Options of the tmpfs mounts, by their path in the container.
 	LogConfig       LogConfig
 	CgroupParent    string // Parent cgroup.
 }
@@ -176,18 +208,26 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 	}
 
 	hostConfig := &HostConfig{
-		ContainerIDFile: job.Getenv("ContainerIDFile"),
-		Memory:          job.GetenvInt64("Memory"),
-		MemorySwap:      job.GetenvInt64("MemorySwap"),
-		CpuShares:       job.GetenvInt64("CpuShares"),
-		CpusetCpus:      job.Getenv("CpusetCpus"),
-		Privileged:      job.GetenvBool("Privileged"),
-		PublishAllPorts: job.GetenvBool("PublishAllPorts"),
-		NetworkMode:     NetworkMode(job.Getenv("NetworkMode")),
-		IpcMode:         IpcMode(job.Getenv("IpcMode")),
-		PidMode:         PidMode(job.Getenv("PidMode")),
-		ReadonlyRootfs:  job.GetenvBool("ReadonlyRootfs"),
-		CgroupParent:    job.Getenv("CgroupParent"),
+		ContainerIDFile:   job.Getenv("ContainerIDFile"),
+		Memory:            job.GetenvInt64("Memory"),
+		MemorySwap:        job.GetenvInt64("MemorySwap"),
+		CpuShares:         job.GetenvInt64("CpuShares"),
+		CpusetCpus:        job.Getenv("CpusetCpus"),
+		CpuPeriod:         job.GetenvInt64("CpuPeriod"),
+		CpuQuota:          job.GetenvInt64("CpuQuota"),
+		BlkioWeight:       job.GetenvInt64("BlkioWeight"),
+		MemoryReservation: job.GetenvInt64("MemoryReservation"),
+		KernelMemory:      job.GetenvInt64("KernelMemory"),
+		OomKillDisable:    job.GetenvBool("OomKillDisable"),
+		PidsLimit:         job.GetenvInt64("PidsLimit"),
+		Privileged:        job.GetenvBool("Privileged"),
+		PublishAllPorts:   job.GetenvBool("PublishAllPorts"),
+		NetworkMode:       NetworkMode(job.Getenv("NetworkMode")),
+		IpcMode:           IpcMode(job.Getenv("IpcMode")),
+		PidMode:           PidMode(job.Getenv("PidMode")),
+		ReadonlyRootfs:    job.GetenvBool("ReadonlyRootfs"),
+		Init:              job.GetenvBool("Init"),
+		CgroupParent:      job.Getenv("CgroupParent"),
 	}
 
 	// FIXME: This is for backward compatibility, if people use `Cpuset`
@@ -200,8 +240,14 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 	job.GetenvJson("LxcConf", &hostConfig.LxcConf)
 	job.GetenvJson("PortBindings", &hostConfig.PortBindings)
 	job.GetenvJson("Devices", &hostConfig.Devices)
+	job.GetenvJson("BlkioDeviceReadBps", &hostConfig.BlkioDeviceReadBps)
+	job.GetenvJson("BlkioDeviceWriteBps", &hostConfig.BlkioDeviceWriteBps)
+	job.GetenvJson("BlkioDeviceReadIOps", &hostConfig.BlkioDeviceReadIOps)
+	job.GetenvJson("BlkioDeviceWriteIOps", &hostConfig.BlkioDeviceWriteIOps)
 	job.GetenvJson("RestartPolicy", &hostConfig.RestartPolicy)
 	job.GetenvJson("Ulimits", &hostConfig.Ulimits)
+	job.GetenvJson("Sysctls", &hostConfig.Sysctls)
+	job.GetenvJson("Tmpfs", &hostConfig.Tmpfs)
 	job.GetenvJson("LogConfig", &hostConfig.LogConfig)
 	hostConfig.SecurityOpt = job.GetenvList("SecurityOpt")
 	if Binds := job.GetenvList("Binds"); Binds != nil {
@@ -1,7 +1,10 @@
 package runconfig
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"path"
 	"strconv"
@@ -10,6 +13,7 @@ import (
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/pkg/units"
@@ -50,38 +54,67 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flCapDrop     = opts.NewListOpts(nil)
 		flSecurityOpt = opts.NewListOpts(nil)
 		flLabelsFile  = opts.NewListOpts(nil)
-
-		flNetwork         = cmd.Bool([]string{"#n", "#-networking"}, true, "Enable networking for this container")
-		flPrivileged      = cmd.Bool([]string{"#privileged", "-privileged"}, false, "Give extended privileges to this container")
-		flPidMode         = cmd.String([]string{"-pid"}, "", "PID namespace to use")
-		flPublishAll      = cmd.Bool([]string{"P", "-publish-all"}, false, "Publish all exposed ports to random ports")
-		flStdin           = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
-		flTty             = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
-		flContainerIDFile = cmd.String([]string{"#cidfile", "-cidfile"}, "", "Write the container ID to the file")
-		flEntrypoint      = cmd.String([]string{"#entrypoint", "-entrypoint"}, "", "Overwrite the default ENTRYPOINT of the image")
-		flHostname        = cmd.String([]string{"h", "-hostname"}, "", "Container host name")
-		flMemoryString    = cmd.String([]string{"m", "-memory"}, "", "Memory limit")
-		flMemorySwap      = cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
-		flUser            = cmd.String([]string{"u", "-user"}, "", "Username or UID (format: <name|uid>[:<group|gid>])")
-		flWorkingDir      = cmd.String([]string{"w", "-workdir"}, "", "Working directory inside the container")
-		flCpuShares       = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
-		flCpusetCpus      = cmd.String([]string{"#-cpuset", "-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
-		flNetMode         = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container")
-		flMacAddress      = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
-		flIpcMode         = cmd.String([]string{"-ipc"}, "", "IPC namespace to use")
-		flRestartPolicy   = cmd.String([]string{"-restart"}, "no", "Restart policy to apply when a container exits")
-		flReadonlyRootfs  = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
-		flLoggingDriver   = cmd.String([]string{"-log-driver"}, "", "Logging driver for container")
-		flCgroupParent    = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
-		flIP              = cmd.String([]string{"ip", "-ip"}, "", "Fixed IP address for the container.")
-		flRestrictIP      = cmd.String([]string{"-restrict-ip"}, "", "Comma separated restricted IP addresses the container allowed to visit.")
-		flMarkNum         = cmd.Int64([]string{"-set-mark"}, 0, "Used to tag network packet of containers")
+		flLoggingOpts = opts.NewListOpts(opts.ValidateLogOpt)
+		flSysctls     = opts.NewListOpts(opts.ValidateSysctl)
+		flTmpfs       = opts.NewListOpts(nil)
+
+		flDeviceReadBps   = opts.NewListOpts(nil)
+		flDeviceWriteBps  = opts.NewListOpts(nil)
+		flDeviceReadIOps  = opts.NewListOpts(nil)
+		flDeviceWriteIOps = opts.NewListOpts(nil)
+
+		flNetwork           = cmd.Bool([]string{"#n", "#-networking"}, true, "Enable networking for this container")
+		flPrivileged        = cmd.Bool([]string{"#privileged", "-privileged"}, false, "Give extended privileges to this container")
+		flPidMode           = cmd.String([]string{"-pid"}, "", "PID namespace to use")
+		flPublishAll        = cmd.Bool([]string{"P", "-publish-all"}, false, "Publish all exposed ports to random ports")
+		flStdin             = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
+		flTty               = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
+		flContainerIDFile   = cmd.String([]string{"#cidfile", "-cidfile"}, "", "Write the container ID to the file")
+		flEntrypoint        = cmd.String([]string{"#entrypoint", "-entrypoint"}, "", "Overwrite the default ENTRYPOINT of the image")
+		flHostname          = cmd.String([]string{"h", "-hostname"}, "", "Container host name")
+		flMemoryString      = cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+		flMemorySwap        = cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
+		flUser              = cmd.String([]string{"u", "-user"}, "", "Username or UID (format: <name|uid>[:<group|gid>])")
+		flWorkingDir        = cmd.String([]string{"w", "-workdir"}, "", "Working directory inside the container")
+		flCpuShares         = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
+		flCpusetCpus        = cmd.String([]string{"#-cpuset", "-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+		flCpuPeriod         = cmd.Int64([]string{"-cpu-period"}, 0, "Limit CPU CFS (Completely Fair Scheduler) period")
+		flCpuQuota          = cmd.Int64([]string{"-cpu-quota"}, 0, "Limit CPU CFS (Completely Fair Scheduler) quota")
+		flBlkioWeight       = cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO weight (relative weight), between 10 and 1000")
+		flMemoryReservation = cmd.String([]string{"-memory-reservation"}, "", "Memory soft limit")
+		flKernelMemory      = cmd.String([]string{"-kernel-memory"}, "", "Kernel memory limit")
+		flOomKillDisable    = cmd.Bool([]string{"-oom-kill-disable"}, false, "Disable OOM Killer")
+		flPidsLimit         = cmd.Int64([]string{"-pids-limit"}, 0, "Tune container pids limit (set 0 for unlimited)")
+		flNetMode           = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container")
+		flMacAddress        = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
+		flIpcMode           = cmd.String([]string{"-ipc"}, "", "IPC namespace to use")
+		flRestartPolicy     = cmd.String([]string{"-restart"}, "no", "Restart policy to apply when a container exits")
+		flRestartGrace      = cmd.Duration([]string{"-restart-grace-period"}, 0, "Time after a start during which an unhealthy container isn't restarted")
+		flRestartDelay      = cmd.Duration([]string{"-restart-delay"}, 0, "Delay before restarting the container, doubled after each quick exit")
+		flRestartMaxDelay   = cmd.Duration([]string{"-restart-max-delay"}, 0, "Maximum delay before restarting the container")
+		flRestartReset      = cmd.Duration([]string{"-restart-reset-window"}, 0, "Time the container must run to reset the restart delay")
+		flReadonlyRootfs    = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
+		flInit              = cmd.Bool([]string{"-init"}, false, "Run an init inside the container that forwards signals and reaps processes")
+		flLoggingDriver     = cmd.String([]string{"-log-driver"}, "", "Logging driver for container")
+		flCgroupParent      = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+		flIP                = cmd.String([]string{"ip", "-ip"}, "", "Fixed IP address for the container.")
+		flRestrictIP        = cmd.String([]string{"-restrict-ip"}, "", "Comma separated restricted IP addresses the container allowed to visit.")
+		flMarkNum           = cmd.Int64([]string{"-set-mark"}, 0, "Used to tag network packet of containers")
+		flHealthCmd         = cmd.String([]string{"-health-cmd"}, "", "Command to run to check health")
+		flHealthInterval    = cmd.Duration([]string{"-health-interval"}, 0, "Time between running the check")
+		flHealthTimeout     = cmd.Duration([]string{"-health-timeout"}, 0, "Maximum time to allow one check to run")
+		flHealthRetries     = cmd.Int([]string{"-health-retries"}, 0, "Consecutive failures needed to report unhealthy")
+		flNoHealthcheck     = cmd.Bool([]string{"-no-healthcheck"}, false, "Disable any container-specified HEALTHCHECK")
 	)
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR")
 	cmd.Var(&flVolumes, []string{"v", "-volume"}, "Bind mount a volume")
 	cmd.Var(&flLinks, []string{"#link", "-link"}, "Add link to another container")
 	cmd.Var(&flDevices, []string{"-device"}, "Add a host device to the container")
+	cmd.Var(&flDeviceReadBps, []string{"-device-read-bps"}, "Limit read rate (bytes per second) from a device")
+	cmd.Var(&flDeviceWriteBps, []string{"-device-write-bps"}, "Limit write rate (bytes per second) to a device")
+	cmd.Var(&flDeviceReadIOps, []string{"-device-read-iops"}, "Limit read rate (IO per second) from a device")
+	cmd.Var(&flDeviceWriteIOps, []string{"-device-write-iops"}, "Limit write rate (IO per second) to a device")
 	cmd.Var(&flLabels, []string{"l", "-label"}, "Set meta data on a container")
 	cmd.Var(&flLabelsFile, []string{"-label-file"}, "Read in a line delimited file of labels")
 	cmd.Var(&flEnv, []string{"e", "-env"}, "Set environment variables")
@@ -97,6 +130,9 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
 	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options")
+	cmd.Var(&flSysctls, []string{"-sysctl"}, "Sysctl options")
+	cmd.Var(&flTmpfs, []string{"-tmpfs"}, "Mount a tmpfs directory")
+	cmd.Var(&flLoggingOpts, []string{"-log-opt"}, "Log driver options")
 
 	cmd.Require(flag.Min, 1)
 
@@ -172,6 +208,56 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	var memoryReservation int64
+	if *flMemoryReservation != "" {
+		parsedMemoryReservation, err := units.RAMInBytes(*flMemoryReservation)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		memoryReservation = parsedMemoryReservation
+	}
+
+	var kernelMemory int64
+	if *flKernelMemory != "" {
+		parsedKernelMemory, err := units.RAMInBytes(*flKernelMemory)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		kernelMemory = parsedKernelMemory
+	}
+
+	if *flCpuPeriod < 0 || *flCpuQuota < 0 {
+		return nil, nil, cmd, fmt.Errorf("--cpu-period and --cpu-quota can't be negative")
+	}
+	if *flPidsLimit < 0 {
+		return nil, nil, cmd, fmt.Errorf("--pids-limit can't be negative")
+	}
+	if *flBlkioWeight != 0 && (*flBlkioWeight < 10 || *flBlkioWeight > 1000) {
+		return nil, nil, cmd, fmt.Errorf("--blkio-weight must be between 10 and 1000")
+	}
+
+	deviceReadBps, err := parseThrottleDevices(flDeviceReadBps.GetAll(), true)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	deviceWriteBps, err := parseThrottleDevices(flDeviceWriteBps.GetAll(), true)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	deviceReadIOps, err := parseThrottleDevices(flDeviceReadIOps.GetAll(), false)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	deviceWriteIOps, err := parseThrottleDevices(flDeviceWriteIOps.GetAll(), false)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	securityOpts, err := parseSecurityOpts(flSecurityOpt.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	var binds []string
 	// add any bind targets to the list of container volumes
 	for bind := range flVolumes.GetMap() {
@@ -252,6 +338,11 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		deviceMappings = append(deviceMappings, deviceMapping)
 	}
 
+	tmpfs, err := ParseTmpfs(flTmpfs.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	// collect all the environment variables for the container
 	envVariables, err := readKVStrings(flEnvFile.GetAll(), flEnv.GetAll())
 	if err != nil {
@@ -289,6 +380,51 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	if err != nil {
 		return nil, nil, cmd, err
 	}
+	if *flRestartGrace != 0 {
+		if restartPolicy.Name != "on-unhealthy" {
+			return nil, nil, cmd, fmt.Errorf("--restart-grace-period only applies to the on-unhealthy restart policy")
+		}
+		if *flRestartGrace < 0 {
+			return nil, nil, cmd, fmt.Errorf("--restart-grace-period can't be negative")
+		}
+		restartPolicy.GracePeriod = *flRestartGrace
+	}
+	if *flRestartDelay != 0 || *flRestartMaxDelay != 0 || *flRestartReset != 0 {
+		if restartPolicy.Name == "" || restartPolicy.Name == "no" {
+			return nil, nil, cmd, fmt.Errorf("--restart-delay, --restart-max-delay and --restart-reset-window need a restart policy")
+		}
+		if *flRestartDelay < 0 || *flRestartMaxDelay < 0 || *flRestartReset < 0 {
+			return nil, nil, cmd, fmt.Errorf("--restart-delay, --restart-max-delay and --restart-reset-window can't be negative")
+		}
+		if *flRestartMaxDelay != 0 && *flRestartMaxDelay < *flRestartDelay {
+			return nil, nil, cmd, fmt.Errorf("--restart-max-delay can't be less than --restart-delay")
+		}
+		restartPolicy.Delay = *flRestartDelay
+		restartPolicy.MaxDelay = *flRestartMaxDelay
+		restartPolicy.ResetWindow = *flRestartReset
+	}
+
+	var healthConfig *HealthConfig
+	haveHealthSettings := *flHealthCmd != "" || *flHealthInterval != 0 || *flHealthTimeout != 0 || *flHealthRetries != 0
+	if *flNoHealthcheck {
+		if haveHealthSettings {
+			return nil, nil, cmd, fmt.Errorf("--no-healthcheck conflicts with --health-* options")
+		}
+		healthConfig = &HealthConfig{Test: []string{"NONE"}}
+	} else if haveHealthSettings {
+		if *flHealthInterval < 0 || *flHealthTimeout < 0 || *flHealthRetries < 0 {
+			return nil, nil, cmd, fmt.Errorf("--health-interval, --health-timeout and --health-retries can't be negative")
+		}
+		healthConfig = &HealthConfig{
+			Interval: *flHealthInterval,
+			Timeout:  *flHealthTimeout,
+			Retries:  *flHealthRetries,
+		}
+		if *flHealthCmd != "" {
+			healthConfig.Test = []string{"CMD-SHELL", *flHealthCmd}
+		}
+	}
+
 	config := &Config{
 		Hostname:        hostname,
 		Domainname:      domainname,
@@ -316,36 +452,51 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		Labels:          convertKVStringsToMap(labels),
 		RestrictIP:      *flRestrictIP,
 		MarkNum:         *flMarkNum,
+		Healthcheck:     healthConfig,
 	}
 
 	hostConfig := &HostConfig{
-		Binds:           binds,
-		ContainerIDFile: *flContainerIDFile,
-		LxcConf:         lxcConf,
-		Memory:          flMemory,
-		MemorySwap:      MemorySwap,
-		CpuShares:       *flCpuShares,
-		CpusetCpus:      *flCpusetCpus,
-		Privileged:      *flPrivileged,
-		PortBindings:    portBindings,
-		Links:           flLinks.GetAll(),
-		PublishAllPorts: *flPublishAll,
-		Dns:             flDns.GetAll(),
-		DnsSearch:       flDnsSearch.GetAll(),
-		ExtraHosts:      flExtraHosts.GetAll(),
-		VolumesFrom:     flVolumesFrom.GetAll(),
-		NetworkMode:     netMode,
-		IpcMode:         ipcMode,
-		PidMode:         pidMode,
-		Devices:         deviceMappings,
-		CapAdd:          flCapAdd.GetAll(),
-		CapDrop:         flCapDrop.GetAll(),
-		RestartPolicy:   restartPolicy,
-		SecurityOpt:     flSecurityOpt.GetAll(),
-		ReadonlyRootfs:  *flReadonlyRootfs,
-		Ulimits:         flUlimits.GetList(),
-		LogConfig:       LogConfig{Type: *flLoggingDriver},
-		CgroupParent:    *flCgroupParent,
+		Binds:                binds,
+		ContainerIDFile:      *flContainerIDFile,
+		LxcConf:              lxcConf,
+		Memory:               flMemory,
+		MemorySwap:           MemorySwap,
+		CpuShares:            *flCpuShares,
+		CpusetCpus:           *flCpusetCpus,
+		CpuPeriod:            *flCpuPeriod,
+		CpuQuota:             *flCpuQuota,
+		BlkioWeight:          *flBlkioWeight,
+		BlkioDeviceReadBps:   deviceReadBps,
+		BlkioDeviceWriteBps:  deviceWriteBps,
+		BlkioDeviceReadIOps:  deviceReadIOps,
+		BlkioDeviceWriteIOps: deviceWriteIOps,
+		MemoryReservation:    memoryReservation,
+		KernelMemory:         kernelMemory,
+		OomKillDisable:       *flOomKillDisable,
+		PidsLimit:            *flPidsLimit,
+		Privileged:           *flPrivileged,
+		PortBindings:         portBindings,
+		Links:                flLinks.GetAll(),
+		PublishAllPorts:      *flPublishAll,
+		Dns:                  flDns.GetAll(),
+		DnsSearch:            flDnsSearch.GetAll(),
+		ExtraHosts:           flExtraHosts.GetAll(),
+		VolumesFrom:          flVolumesFrom.GetAll(),
+		NetworkMode:          netMode,
+		IpcMode:              ipcMode,
+		PidMode:              pidMode,
+		Devices:              deviceMappings,
+		CapAdd:               flCapAdd.GetAll(),
+		CapDrop:              flCapDrop.GetAll(),
+		RestartPolicy:        restartPolicy,
+		SecurityOpt:          securityOpts,
+		ReadonlyRootfs:       *flReadonlyRootfs,
+		Init:                 *flInit,
+		Ulimits:              flUlimits.GetList(),
+		Sysctls:              convertKVStringsToMap(flSysctls.GetAll()),
+		Tmpfs:                tmpfs,
+		LogConfig:            LogConfig{Type: *flLoggingDriver, Config: convertKVStringsToMap(flLoggingOpts.GetAll())},
+		CgroupParent:         *flCgroupParent,
 	}
 
 	// When allocating stdin in attached mode, close stdin at client disconnect
@@ -401,13 +552,13 @@ func parseRestartPolicy(policy string) (RestartPolicy, error) {
 
 	p.Name = name
 	switch name {
-	case "always":
+	case "always", "unless-stopped":
 		if len(parts) == 2 {
-			return p, fmt.Errorf("maximum restart count not valid with restart policy of \"always\"")
+			return p, fmt.Errorf("maximum restart count not valid with restart policy of %q", name)
 		}
 	case "no":
 		// do nothing
-	case "on-failure":
+	case "on-failure", "on-unhealthy":
 		if len(parts) == 2 {
 			count, err := strconv.Atoi(parts[1])
 			if err != nil {
@@ -497,3 +648,81 @@ func ParseDevice(device string) (DeviceMapping, error) {
 	}
 	return deviceMapping, nil
 }
+
+// ParseTmpfs parses the PATH[:OPTIONS] specifications of tmpfs mounts, where
+// the options are comma separated mount options like size=64m,mode=1777
+func ParseTmpfs(tmpfs []string) (map[string]string, error) {
+	mounts := make(map[string]string)
+	for _, t := range tmpfs {
+		arr := strings.SplitN(t, ":", 2)
+		dest := path.Clean(arr[0])
+		if !path.IsAbs(dest) || dest == "/" {
+			return nil, fmt.Errorf("Invalid tmpfs: %s, the path must be absolute and can't be /", t)
+		}
+		if _, exists := mounts[dest]; exists {
+			return nil, fmt.Errorf("Duplicate tmpfs path: %s", dest)
+		}
+		options := ""
+		if len(arr) == 2 {
+			options = arr[1]
+		}
+		if _, _, err := mount.ParseTmpfsOptions(options); err != nil {
+			return nil, fmt.Errorf("Invalid tmpfs: %s, %v", t, err)
+		}
+		mounts[dest] = options
+	}
+	return mounts, nil
+}
+
+// parseSecurityOpts replaces the path of the JSON file of a custom seccomp
+// profile given by seccomp=PATH with the profile itself, since the file is
+// on the client. The "default" and "unconfined" profiles are kept as is.
+func parseSecurityOpts(securityOpts []string) ([]string, error) {
+	for i, opt := range securityOpts {
+		if !strings.HasPrefix(opt, "seccomp=") && !strings.HasPrefix(opt, "seccomp:") {
+			continue
+		}
+		profile := opt[len("seccomp="):]
+		if profile == "unconfined" || profile == "default" {
+			continue
+		}
+		body, err := ioutil.ReadFile(profile)
+		if err != nil {
+			return nil, fmt.Errorf("Opening seccomp profile %s failed: %v", profile, err)
+		}
+		buf := &bytes.Buffer{}
+		if err := json.Compact(buf, body); err != nil {
+			return nil, fmt.Errorf("Invalid seccomp profile %s: %v", profile, err)
+		}
+		securityOpts[i] = "seccomp=" + buf.String()
+	}
+	return securityOpts, nil
+}
+
+// parseThrottleDevices parses the PATH:RATE limits of block devices, where
+// the rate is a size like 1mb when bytes is true, else a number of operations
+func parseThrottleDevices(devices []string, bytes bool) ([]ThrottleDevice, error) {
+	throttleDevices := []ThrottleDevice{}
+	for _, device := range devices {
+		arr := strings.Split(device, ":")
+		if len(arr) != 2 || !strings.HasPrefix(arr[0], "/dev/") {
+			return nil, fmt.Errorf("Invalid device rate limit: %s, it must be PATH:RATE with a path in /dev/", device)
+		}
+		var rate uint64
+		if bytes {
+			n, err := units.RAMInBytes(arr[1])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("Invalid rate in device rate limit: %s, it must be a positive size like 1mb", device)
+			}
+			rate = uint64(n)
+		} else {
+			n, err := strconv.ParseUint(arr[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid rate in device rate limit: %s, it must be a positive number of IO per second", device)
+			}
+			rate = n
+		}
+		throttleDevices = append(throttleDevices, ThrottleDevice{Path: arr[0], Rate: rate})
+	}
+	return throttleDevices, nil
+}
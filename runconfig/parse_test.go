@@ -2,7 +2,10 @@ package runconfig
 
 import (
 	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
@@ -57,3 +60,191 @@ func TestNetHostname(t *testing.T) {
 		t.Fatalf("Expected error ErrConflictNetworkHostname, got: %s", err)
 	}
 }
+
+func TestParseHealth(t *testing.T) {
+	config, _, _, err := parseRun([]string{"--health-cmd=curl -f http://localhost/", "--health-interval=5s", "--health-retries=2", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc := config.Healthcheck
+	if hc == nil || len(hc.Test) != 2 || hc.Test[0] != "CMD-SHELL" || hc.Test[1] != "curl -f http://localhost/" {
+		t.Fatalf("Unexpected health check %+v", hc)
+	}
+	if hc.Interval != 5*time.Second || hc.Timeout != 0 || hc.Retries != 2 {
+		t.Fatalf("Unexpected health check settings %+v", hc)
+	}
+
+	config, _, _, err = parseRun([]string{"--no-healthcheck", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hc := config.Healthcheck; hc == nil || len(hc.Test) != 1 || hc.Test[0] != "NONE" {
+		t.Fatalf("Expected a NONE health check, got %+v", hc)
+	}
+
+	if _, _, _, err := parseRun([]string{"--no-healthcheck", "--health-cmd=true", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for --no-healthcheck with --health-cmd")
+	}
+	if _, _, _, err := parseRun([]string{"--health-retries=-1", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for negative retries")
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--restart=on-unhealthy:3", "--restart-grace-period=1m", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := hostConfig.RestartPolicy
+	if p.Name != "on-unhealthy" || p.MaximumRetryCount != 3 || p.GracePeriod != time.Minute {
+		t.Fatalf("Unexpected restart policy %+v", p)
+	}
+
+	if _, _, _, err := parseRun([]string{"--restart=always", "--restart-grace-period=1m", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a grace period without the on-unhealthy policy")
+	}
+	if _, _, _, err := parseRun([]string{"--restart=sometimes", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for an unknown restart policy")
+	}
+}
+
+func TestParseRestartDelay(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--restart=unless-stopped", "--restart-delay=1s", "--restart-max-delay=1m", "--restart-reset-window=30s", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := hostConfig.RestartPolicy
+	if p.Name != "unless-stopped" || p.Delay != time.Second || p.MaxDelay != time.Minute || p.ResetWindow != 30*time.Second {
+		t.Fatalf("Unexpected restart policy %+v", p)
+	}
+
+	for _, args := range [][]string{
+		{"--restart=unless-stopped:3", "img", "cmd"},
+		{"--restart-delay=1s", "img", "cmd"},
+		{"--restart=always", "--restart-delay=-1s", "img", "cmd"},
+		{"--restart=always", "--restart-delay=1m", "--restart-max-delay=1s", "img", "cmd"},
+	} {
+		if _, _, _, err := parseRun(args); err == nil {
+			t.Fatalf("Expected an error for %v", args)
+		}
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--cpu-period=50000", "--cpu-quota=25000", "--blkio-weight=300",
+		"--device-read-bps=/dev/sda:1mb", "--device-write-iops=/dev/sdb:100",
+		"--memory-reservation=64m", "--kernel-memory=32m", "--oom-kill-disable", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.CpuPeriod != 50000 || hostConfig.CpuQuota != 25000 || hostConfig.BlkioWeight != 300 {
+		t.Fatalf("Unexpected CPU and block IO settings %+v", hostConfig)
+	}
+	if hostConfig.MemoryReservation != 64<<20 || hostConfig.KernelMemory != 32<<20 || !hostConfig.OomKillDisable {
+		t.Fatalf("Unexpected memory settings %+v", hostConfig)
+	}
+	if hostConfig.PidsLimit != 0 {
+		t.Fatalf("Expected no pids limit, got %d", hostConfig.PidsLimit)
+	}
+	if len(hostConfig.BlkioDeviceReadBps) != 1 || hostConfig.BlkioDeviceReadBps[0] != (ThrottleDevice{Path: "/dev/sda", Rate: 1 << 20}) {
+		t.Fatalf("Unexpected read rate limits %v", hostConfig.BlkioDeviceReadBps)
+	}
+	if len(hostConfig.BlkioDeviceWriteIOps) != 1 || hostConfig.BlkioDeviceWriteIOps[0] != (ThrottleDevice{Path: "/dev/sdb", Rate: 100}) {
+		t.Fatalf("Unexpected write rate limits %v", hostConfig.BlkioDeviceWriteIOps)
+	}
+
+	for _, args := range [][]string{
+		{"--blkio-weight=5", "img", "cmd"},
+		{"--cpu-quota=-1", "img", "cmd"},
+		{"--device-read-bps=/dev/sda", "img", "cmd"},
+		{"--device-read-bps=sda:1mb", "img", "cmd"},
+		{"--device-write-iops=/dev/sda:1mb", "img", "cmd"},
+		{"--kernel-memory=lots", "img", "cmd"},
+		{"--pids-limit=-1", "img", "cmd"},
+	} {
+		if _, _, _, err := parseRun(args); err == nil {
+			t.Fatalf("Expected an error for %v", args)
+		}
+	}
+}
+
+func TestParsePidsLimit(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--pids-limit=100", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.PidsLimit != 100 {
+		t.Fatalf("Expected a pids limit of 100, got %d", hostConfig.PidsLimit)
+	}
+}
+
+func TestParseSysctls(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--sysctl", "net.core.somaxconn=1024", "--sysctl", "kernel.sem=250 32000 100 128", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.Sysctls) != 2 || hostConfig.Sysctls["net.core.somaxconn"] != "1024" || hostConfig.Sysctls["kernel.sem"] != "250 32000 100 128" {
+		t.Fatalf("Unexpected sysctls %v", hostConfig.Sysctls)
+	}
+	if _, _, _, err := parseRun([]string{"--sysctl", "vm.swappiness=10", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a sysctl which isn't namespaced")
+	}
+}
+
+func TestParseTmpfs(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--tmpfs", "/run", "--tmpfs", "/tmp/:size=64m,mode=1777", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.Tmpfs) != 2 || hostConfig.Tmpfs["/run"] != "" || hostConfig.Tmpfs["/tmp"] != "size=64m,mode=1777" {
+		t.Fatalf("Unexpected tmpfs %v", hostConfig.Tmpfs)
+	}
+	for _, tmpfs := range []string{"run", "/", "/tmp:foo=bar", "/tmp:rbind"} {
+		if _, _, _, err := parseRun([]string{"--tmpfs", tmpfs, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for the tmpfs %q", tmpfs)
+		}
+	}
+	if _, _, _, err := parseRun([]string{"--tmpfs", "/run", "--tmpfs", "/run/:size=1m", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a duplicate tmpfs path")
+	}
+}
+
+func TestParseSeccompProfile(t *testing.T) {
+	f, err := ioutil.TempFile("", "seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("{\n  \"defaultAction\": \"SCMP_ACT_ALLOW\"\n}\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, hostConfig, _, err := parseRun([]string{"--security-opt", "seccomp=" + f.Name(), "--security-opt", "seccomp=unconfined", "--security-opt", "seccomp=default", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`seccomp={"defaultAction":"SCMP_ACT_ALLOW"}`, "seccomp=unconfined", "seccomp=default"}
+	if !reflect.DeepEqual(hostConfig.SecurityOpt, expected) {
+		t.Fatalf("Expected the security options %v, got %v", expected, hostConfig.SecurityOpt)
+	}
+	if _, _, _, err := parseRun([]string{"--security-opt", "seccomp=/nonexistent.json", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a missing seccomp profile")
+	}
+}
+
+func TestParseInit(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.Init {
+		t.Fatal("Expected no init by default")
+	}
+	if _, hostConfig, _, err = parseRun([]string{"--init", "img", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if !hostConfig.Init {
+		t.Fatal("Expected --init to run an init")
+	}
+}